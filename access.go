@@ -0,0 +1,236 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type Access string
+
+const (
+	AccessConfigure Access = "configure"
+	AccessWrite     Access = "write"
+	AccessRead      Access = "read"
+)
+
+// AccessCheck is the explained result of a permission evaluation.
+type AccessCheck struct {
+	Allowed  bool
+	User     string
+	Vhost    string
+	Resource string
+	Access   Access
+
+	// Pattern is the regular expression the resource was matched against.
+	// It's empty if no pattern applied.
+	Pattern string
+
+	// Reason describes why access is allowed or denied.
+	Reason string
+}
+
+func (c AccessCheck) String() string {
+	verdict := "denied"
+	if c.Allowed {
+		verdict = "allowed"
+	}
+
+	return fmt.Sprintf("%s %s on '%s' in vhost '%s' for user '%s': %s",
+		c.Access, verdict, c.Resource, c.Vhost, c.User, c.Reason)
+}
+
+// Evaluator interprets permissions and topic permissions the same way the
+// broker does:
+//
+//   - a user without permissions on a vhost can't access anything in it
+//   - an empty pattern denies access to every resource
+//   - patterns are regular expressions which are not implicitly anchored
+//     (as in RabbitMQ), so "^tenant-" must be written with a caret and ".*"
+//     allows every resource
+//   - the default exchange is checked as "amq.default"
+//   - topic permissions are only checked if there are topic permissions for
+//     the user, vhost and exchange; otherwise access is allowed
+type Evaluator struct {
+	permissions      []Permission
+	topicPermissions []TopicPermission
+
+	mu      sync.Mutex // guards regexps
+	regexps map[string]*regexp.Regexp
+}
+
+// NewEvaluator returns an evaluator for the given permissions, e.g. from a
+// definitions export.
+func NewEvaluator(permissions []Permission, topicPermissions []TopicPermission) *Evaluator {
+	return &Evaluator{
+		permissions:      permissions,
+		topicPermissions: topicPermissions,
+		regexps:          make(map[string]*regexp.Regexp),
+	}
+}
+
+// Evaluator returns an evaluator for the current permissions and topic
+// permissions of the broker.
+func (r *Rabbit) Evaluator() (*Evaluator, error) {
+	permissions, err := r.GetPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	topicPermissions, err := r.GetTopicPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEvaluator(permissions, topicPermissions), nil
+}
+
+// CanConfigure reports whether user can create, delete or change the resource
+// (an exchange or queue name) in vhost.
+func (e *Evaluator) CanConfigure(user, vhost, resource string) bool {
+	return e.Explain(AccessConfigure, user, vhost, resource).Allowed
+}
+
+// CanWrite reports whether user can publish to the exchange, or bind to the
+// queue, named resource in vhost.
+func (e *Evaluator) CanWrite(user, vhost, resource string) bool {
+	return e.Explain(AccessWrite, user, vhost, resource).Allowed
+}
+
+// CanRead reports whether user can consume from the queue, or bind from the
+// exchange, named resource in vhost.
+func (e *Evaluator) CanRead(user, vhost, resource string) bool {
+	return e.Explain(AccessRead, user, vhost, resource).Allowed
+}
+
+// CanWriteTopic reports whether user can publish to the topic exchange with
+// the routing key.
+func (e *Evaluator) CanWriteTopic(user, vhost, exchange, routingKey string) bool {
+	return e.ExplainTopic(AccessWrite, user, vhost, exchange, routingKey).Allowed
+}
+
+// CanReadTopic reports whether user can bind a queue to the topic exchange
+// with the routing key.
+func (e *Evaluator) CanReadTopic(user, vhost, exchange, routingKey string) bool {
+	return e.ExplainTopic(AccessRead, user, vhost, exchange, routingKey).Allowed
+}
+
+// Explain evaluates access to the resource and reports which pattern decided
+// the outcome.
+func (e *Evaluator) Explain(access Access, user, vhost, resource string) AccessCheck {
+	check := AccessCheck{
+		User:     user,
+		Vhost:    vhost,
+		Resource: resource,
+		Access:   access,
+	}
+
+	if resource == "" {
+		resource = "amq.default"
+	}
+
+	var permission *Permission
+	for i, p := range e.permissions {
+		if p.User == user && p.Vhost == vhost {
+			permission = &e.permissions[i]
+			break
+		}
+	}
+
+	if permission == nil {
+		check.Reason = "user has no permissions on the vhost"
+		return check
+	}
+
+	switch access {
+	case AccessConfigure:
+		check.Pattern = permission.Configure
+	case AccessWrite:
+		check.Pattern = permission.Write
+	case AccessRead:
+		check.Pattern = permission.Read
+	default:
+		check.Reason = fmt.Sprintf("unknown access '%s'", access)
+		return check
+	}
+
+	check.Allowed, check.Reason = e.match(check.Pattern, resource)
+	return check
+}
+
+// ExplainTopic evaluates topic access to the exchange with the routing key
+// and reports which pattern decided the outcome. The "{username}" and
+// "{vhost}" variables in patterns are expanded.
+func (e *Evaluator) ExplainTopic(access Access, user, vhost, exchange, routingKey string) AccessCheck {
+	check := AccessCheck{
+		User:     user,
+		Vhost:    vhost,
+		Resource: exchange + " " + routingKey,
+		Access:   access,
+	}
+
+	var permission *TopicPermission
+	for i, p := range e.topicPermissions {
+		if p.User == user && p.Vhost == vhost && p.Exchange == exchange {
+			permission = &e.topicPermissions[i]
+			break
+		}
+	}
+
+	if permission == nil {
+		check.Allowed = true
+		check.Reason = "no topic permissions for the exchange"
+		return check
+	}
+
+	switch access {
+	case AccessWrite:
+		check.Pattern = permission.Write
+	case AccessRead:
+		check.Pattern = permission.Read
+	default:
+		check.Reason = fmt.Sprintf("access '%s' doesn't apply to topics", access)
+		return check
+	}
+
+	check.Pattern = strings.NewReplacer("{username}", user, "{vhost}", vhost).Replace(check.Pattern)
+	check.Allowed, check.Reason = e.match(check.Pattern, routingKey)
+	return check
+}
+
+func (e *Evaluator) match(pattern, resource string) (bool, string) {
+	if pattern == "" {
+		return false, "empty pattern denies all resources"
+	}
+
+	re, err := e.regexp(pattern)
+	if err != nil {
+		return false, fmt.Sprintf("invalid pattern '%s': %s", pattern, err)
+	}
+
+	if !re.MatchString(resource) {
+		return false, fmt.Sprintf("'%s' doesn't match pattern '%s'", resource, pattern)
+	}
+
+	return true, fmt.Sprintf("'%s' matches pattern '%s'", resource, pattern)
+}
+
+// regexp returns the compiled pattern. Patterns are compiled once, topic
+// patterns once per expansion.
+func (e *Evaluator) regexp(pattern string) (*regexp.Regexp, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if re, ok := e.regexps[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	e.regexps[pattern] = re
+
+	return re, nil
+}
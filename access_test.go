@@ -0,0 +1,69 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRabbit_Evaluator(t *testing.T) {
+	e := NewEvaluator([]Permission{
+		{User: "tenant", Vhost: "shared", Configure: "^tenant-", Write: ".*", Read: ""},
+		{User: "admin", Vhost: "shared", Configure: ".*", Write: ".*", Read: ".*"},
+	}, []TopicPermission{
+		{User: "tenant", Vhost: "shared", Exchange: "amq.topic", Write: "^{username}\\.", Read: ".*"},
+	})
+
+	tests := []struct {
+		allowed bool
+		actual  bool
+	}{
+		{true, e.CanConfigure("tenant", "shared", "tenant-jobs")},
+		{false, e.CanConfigure("tenant", "shared", "other-jobs")},
+		{true, e.CanWrite("tenant", "shared", "")},
+		{false, e.CanRead("tenant", "shared", "tenant-jobs")},
+		{false, e.CanRead("tenant", "other", "tenant-jobs")},
+		{true, e.CanRead("admin", "shared", "tenant-jobs")},
+		{true, e.CanWriteTopic("tenant", "shared", "amq.topic", "tenant.created")},
+		{false, e.CanWriteTopic("tenant", "shared", "amq.topic", "admin.created")},
+		{true, e.CanWriteTopic("tenant", "shared", "events", "admin.created")},
+	}
+
+	for i, test := range tests {
+		if test.allowed != test.actual {
+			t.Errorf("check %d: expected %v, got %v", i, test.allowed, test.actual)
+		}
+	}
+
+	check := e.Explain(AccessRead, "tenant", "shared", "tenant-jobs")
+	if check.Pattern != "" || check.Reason != "empty pattern denies all resources" {
+		t.Errorf("unexpected explanation: %s", check)
+	}
+
+	check = e.Explain(AccessConfigure, "tenant", "shared", "tenant-jobs")
+	if check.Pattern != "^tenant-" || !check.Allowed {
+		t.Errorf("unexpected explanation: %s", check)
+	}
+}
+
+func TestRabbit_EvaluatorConcurrent(t *testing.T) {
+	e := NewEvaluator([]Permission{
+		{User: "tenant", Vhost: "shared", Configure: "^tenant-", Write: ".*", Read: "^tenant-"},
+	}, []TopicPermission{
+		{User: "tenant", Vhost: "shared", Exchange: "amq.topic", Write: "^{username}\\.", Read: ".*"},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resource := fmt.Sprintf("tenant-%d", i)
+			if !e.CanRead("tenant", "shared", resource) || !e.CanWrite("tenant", "shared", resource) ||
+				!e.CanWriteTopic("tenant", "shared", "amq.topic", "tenant.created") {
+				t.Errorf("%s: access denied", resource)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,115 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+)
+
+// DestinationType is the kind of object a binding routes messages to.
+type DestinationType string
+
+const (
+	DestinationQueue    DestinationType = "queue"
+	DestinationExchange DestinationType = "exchange"
+)
+
+type Binding struct {
+	Arguments       map[string]interface{} `json:"arguments"`
+	Destination     string                 `json:"destination"`
+	DestinationType DestinationType        `json:"destination_type"`
+	PropertiesKey   string                 `json:"properties_key"`
+	RoutingKey      string                 `json:"routing_key"`
+	Source          string                 `json:"source"`
+	Vhost           string                 `json:"vhost"`
+}
+
+// GetBindings returns a list of all bindings.
+func (r *Rabbit) GetBindings() ([]Binding, error) {
+	body, err := r.doRequest("GET", "/api/bindings", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings := make([]Binding, 0)
+	err = json.Unmarshal(body, &bindings)
+	if err != nil {
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+// GetVhostBindings returns a list of all bindings in a given virtual host.
+func (r *Rabbit) GetVhostBindings(vhost string) ([]Binding, error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	body, err := r.doRequest("GET", "/api/bindings/"+vhost, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings := make([]Binding, 0)
+	err = json.Unmarshal(body, &bindings)
+	if err != nil {
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+// CreateBinding binds the source exchange to the destination, which is either
+// a queue or an exchange depending on destinationType.
+func (r *Rabbit) CreateBinding(vhost, source, destination string, destinationType DestinationType, routingKey string, args map[string]interface{}) error {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	if args == nil {
+		args = make(map[string]interface{}, 0)
+	}
+
+	binding := map[string]interface{}{
+		"routing_key": routingKey,
+		"arguments":   args,
+	}
+
+	data, err := json.Marshal(binding)
+	if err != nil {
+		return err
+	}
+
+	endpoint := "/api/bindings/" + vhost + "/e/" + source + "/" + bindingDestination(destinationType) + "/" + destination
+	_, err = r.doRequest("POST", endpoint, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteBinding deletes an individual binding. propertiesKey is the
+// PropertiesKey field of the binding as returned by GetBindings.
+func (r *Rabbit) DeleteBinding(vhost, source, destination string, destinationType DestinationType, propertiesKey string) error {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	endpoint := "/api/bindings/" + vhost + "/e/" + source + "/" + bindingDestination(destinationType) + "/" + destination + "/" + propertiesKey
+	_, err := r.doRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// bindingDestination returns the path segment used for the given destination
+// type in binding endpoints.
+func bindingDestination(destinationType DestinationType) string {
+	if destinationType == DestinationExchange {
+		return "e"
+	}
+
+	return "q"
+}
@@ -0,0 +1,42 @@
+package rabbitapi
+
+import (
+	"testing"
+)
+
+func TestRabbit_CreateBinding(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	err := r.CreateQueue("/", "rabbitapi-binding", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.CreateBinding("/", "amq.topic", "rabbitapi-binding", DestinationQueue, "rabbitapi.#", nil)
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("binding from 'amq.topic' to 'rabbitapi-binding' is created successfull")
+	}
+}
+
+func TestRabbit_GetVhostBindings(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	bindings, err := r.GetVhostBindings("/")
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("bindings:", bindings)
+	}
+}
+
+func TestRabbit_DeleteBinding(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	err := r.DeleteBinding("/", "amq.topic", "rabbitapi-binding", DestinationQueue, "rabbitapi.%23")
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("binding from 'amq.topic' to 'rabbitapi-binding' is deleted successfully")
+	}
+
+	r.DeleteQueue("/", "rabbitapi-binding")
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/koding/rabbitapi"
+)
+
+type command struct {
+	usage string
+	run   func(r *rabbitapi.Rabbit, args []string) (output, error)
+}
+
+var commands = map[string]command{
+	"overview":               {"", overview},
+	"aliveness":              {"[vhost]", aliveness},
+	"vhosts list":            {"", vhostsList},
+	"vhosts get":             {"<name>", vhostsGet},
+	"vhosts create":          {"<name>", vhostsCreate},
+	"vhosts delete":          {"<name>", vhostsDelete},
+	"users list":             {"", usersList},
+	"users get":              {"<name>", usersGet},
+	"users create":           {"[-password password] [-tags tag,...] <name>", usersCreate},
+	"users delete":           {"<name>", usersDelete},
+	"exchanges list":         {"[-vhost vhost]", exchangesList},
+	"exchanges get":          {"<vhost> <name>", exchangesGet},
+	"exchanges create":       {"[-type type] [-durable] [-auto-delete] [-internal] [-alternate-exchange name] <vhost> <name>", exchangesCreate},
+	"exchanges delete":       {"<vhost> <name>", exchangesDelete},
+	"queues list":            {"[-vhost vhost]", queuesList},
+	"permissions list":       {"", permissionsList},
+	"permissions set":        {"<vhost> <user> <configure> <write> <read>", permissionsSet},
+	"permissions delete":     {"<vhost> <user>", permissionsDelete},
+	"topic-permissions list": {"", topicPermissionsList},
+	"graph":                  {"[-format dot|mermaid] [-file definitions] <vhost>", graph},
+}
+
+// needArgs returns an error unless there are exactly n arguments.
+func needArgs(args []string, n int, names string) error {
+	if len(args) != n {
+		return fmt.Errorf("expected arguments %s, got %d arguments", names, len(args))
+	}
+
+	return nil
+}
+
+func overview(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 0, ""); err != nil {
+		return output{}, err
+	}
+
+	o, err := r.GetOverview()
+	if err != nil {
+		return output{}, err
+	}
+
+	return output{
+		value:   o,
+		headers: []string{"CLUSTER", "NODE", "RABBITMQ", "ERLANG", "CONNECTIONS", "CHANNELS", "EXCHANGES", "QUEUES", "CONSUMERS", "MESSAGES"},
+		rows: [][]string{{
+			o.ClusterName, o.Node, o.RabbitmqVersion, o.ErlangVersion,
+			strconv.Itoa(o.ObjectTotals.Connections),
+			strconv.Itoa(o.ObjectTotals.Channels),
+			strconv.Itoa(o.ObjectTotals.Exchanges),
+			strconv.Itoa(o.ObjectTotals.Queues),
+			strconv.Itoa(o.ObjectTotals.Consumers),
+			strconv.Itoa(o.QueueTotals.Messages),
+		}},
+	}, nil
+}
+
+func aliveness(r *rabbitapi.Rabbit, args []string) (output, error) {
+	vhost := "/"
+	if len(args) == 1 {
+		vhost = args[0]
+	} else if err := needArgs(args, 0, "[vhost]"); err != nil {
+		return output{}, err
+	}
+
+	if err := r.AlivenessTest(vhost); err != nil {
+		return output{}, err
+	}
+
+	return output{message: fmt.Sprintf("vhost '%s' is ok", vhost)}, nil
+}
+
+func vhostRows(vhosts ...rabbitapi.Vhost) [][]string {
+	rows := make([][]string, len(vhosts))
+	for i, v := range vhosts {
+		rows[i] = []string{v.Name, strconv.FormatBool(v.Tracing), strconv.Itoa(v.Messages)}
+	}
+
+	return rows
+}
+
+var vhostHeaders = []string{"NAME", "TRACING", "MESSAGES"}
+
+func vhostsList(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 0, ""); err != nil {
+		return output{}, err
+	}
+
+	vhosts, err := r.GetVhosts()
+	if err != nil {
+		return output{}, err
+	}
+
+	return output{value: vhosts, headers: vhostHeaders, rows: vhostRows(vhosts...)}, nil
+}
+
+func vhostsGet(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 1, "<name>"); err != nil {
+		return output{}, err
+	}
+
+	vhost, err := r.GetVhost(args[0])
+	if err != nil {
+		return output{}, err
+	}
+
+	return output{value: vhost, headers: vhostHeaders, rows: vhostRows(vhost)}, nil
+}
+
+func vhostsCreate(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 1, "<name>"); err != nil {
+		return output{}, err
+	}
+
+	if err := r.CreateVhost(args[0]); err != nil {
+		return output{}, err
+	}
+
+	return changed(fmt.Sprintf("vhost '%s'", args[0]), "create", "created"), nil
+}
+
+func vhostsDelete(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 1, "<name>"); err != nil {
+		return output{}, err
+	}
+
+	if err := r.DeleteVhost(args[0]); err != nil {
+		return output{}, err
+	}
+
+	return changed(fmt.Sprintf("vhost '%s'", args[0]), "delete", "deleted"), nil
+}
+
+func userRows(users ...rabbitapi.User) [][]string {
+	rows := make([][]string, len(users))
+	for i, u := range users {
+		rows[i] = []string{u.Name, u.Tags.String()}
+	}
+
+	return rows
+}
+
+var userHeaders = []string{"NAME", "TAGS"}
+
+func usersList(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 0, ""); err != nil {
+		return output{}, err
+	}
+
+	users, err := r.GetUsers()
+	if err != nil {
+		return output{}, err
+	}
+
+	return output{value: users, headers: userHeaders, rows: userRows(users...)}, nil
+}
+
+func usersGet(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 1, "<name>"); err != nil {
+		return output{}, err
+	}
+
+	user, err := r.GetUser(args[0])
+	if err != nil {
+		return output{}, err
+	}
+
+	return output{value: user, headers: userHeaders, rows: userRows(user)}, nil
+}
+
+func usersCreate(r *rabbitapi.Rabbit, args []string) (output, error) {
+	fs := flag.NewFlagSet("users create", flag.ContinueOnError)
+	password := fs.String("password", "", "password of the user")
+	tags := fs.String("tags", "", "comma separated tags of the user")
+	if err := fs.Parse(args); err != nil {
+		return output{}, err
+	}
+
+	args = fs.Args()
+	if err := needArgs(args, 1, "<name>"); err != nil {
+		return output{}, err
+	}
+
+	if err := r.CreateUser(args[0], *password, rabbitapi.ParseTags(*tags)); err != nil {
+		return output{}, err
+	}
+
+	return changed(fmt.Sprintf("user '%s'", args[0]), "create", "created"), nil
+}
+
+func usersDelete(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 1, "<name>"); err != nil {
+		return output{}, err
+	}
+
+	if err := r.DeleteUser(args[0]); err != nil {
+		return output{}, err
+	}
+
+	return changed(fmt.Sprintf("user '%s'", args[0]), "delete", "deleted"), nil
+}
+
+func exchangeRows(exchanges ...rabbitapi.Exchange) [][]string {
+	rows := make([][]string, len(exchanges))
+	for i, e := range exchanges {
+		rows[i] = []string{
+			e.Vhost, e.Name, e.Type,
+			strconv.FormatBool(e.Durable),
+			strconv.FormatBool(e.AutoDelete),
+			strconv.FormatBool(e.Internal),
+			e.Policy,
+		}
+	}
+
+	return rows
+}
+
+var exchangeHeaders = []string{"VHOST", "NAME", "TYPE", "DURABLE", "AUTO DELETE", "INTERNAL", "POLICY"}
+
+func exchangesList(r *rabbitapi.Rabbit, args []string) (output, error) {
+	fs := flag.NewFlagSet("exchanges list", flag.ContinueOnError)
+	vhost := fs.String("vhost", "", "only list exchanges of the vhost")
+	if err := fs.Parse(args); err != nil {
+		return output{}, err
+	}
+
+	if err := needArgs(fs.Args(), 0, ""); err != nil {
+		return output{}, err
+	}
+
+	var exchanges []rabbitapi.Exchange
+	var err error
+	if *vhost == "" {
+		exchanges, err = r.GetExchanges()
+	} else {
+		exchanges, err = r.GetVhostExchanges(*vhost)
+	}
+	if err != nil {
+		return output{}, err
+	}
+
+	return output{value: exchanges, headers: exchangeHeaders, rows: exchangeRows(exchanges...)}, nil
+}
+
+func exchangesGet(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 2, "<vhost> <name>"); err != nil {
+		return output{}, err
+	}
+
+	exchange, err := r.GetExchange(args[0], args[1])
+	if err != nil {
+		return output{}, err
+	}
+
+	return output{value: exchange, headers: exchangeHeaders, rows: exchangeRows(exchange)}, nil
+}
+
+func exchangesCreate(r *rabbitapi.Rabbit, args []string) (output, error) {
+	fs := flag.NewFlagSet("exchanges create", flag.ContinueOnError)
+	kind := fs.String("type", "direct", "exchange type")
+	durable := fs.Bool("durable", false, "survive broker restarts")
+	autoDelete := fs.Bool("auto-delete", false, "delete when the last binding is removed")
+	internal := fs.Bool("internal", false, "only allow publishing from other exchanges")
+	alternate := fs.String("alternate-exchange", "", "exchange receiving unroutable messages")
+	if err := fs.Parse(args); err != nil {
+		return output{}, err
+	}
+
+	args = fs.Args()
+	if err := needArgs(args, 2, "<vhost> <name>"); err != nil {
+		return output{}, err
+	}
+
+	err := r.CreateExchange(args[0], args[1], rabbitapi.ExchangeOptions{
+		Type:              rabbitapi.ExchangeType(*kind),
+		Durable:           *durable,
+		AutoDelete:        *autoDelete,
+		Internal:          *internal,
+		AlternateExchange: *alternate,
+	})
+	if err != nil {
+		return output{}, err
+	}
+
+	return changed(fmt.Sprintf("exchange '%s' in vhost '%s'", args[1], args[0]), "create", "created"), nil
+}
+
+func exchangesDelete(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 2, "<vhost> <name>"); err != nil {
+		return output{}, err
+	}
+
+	if err := r.DeleteExchange(args[0], args[1]); err != nil {
+		return output{}, err
+	}
+
+	return changed(fmt.Sprintf("exchange '%s' in vhost '%s'", args[1], args[0]), "delete", "deleted"), nil
+}
+
+func queuesList(r *rabbitapi.Rabbit, args []string) (output, error) {
+	fs := flag.NewFlagSet("queues list", flag.ContinueOnError)
+	vhost := fs.String("vhost", "", "only list queues of the vhost")
+	if err := fs.Parse(args); err != nil {
+		return output{}, err
+	}
+
+	if err := needArgs(fs.Args(), 0, ""); err != nil {
+		return output{}, err
+	}
+
+	var queues []rabbitapi.Queue
+	var err error
+	if *vhost == "" {
+		queues, err = r.GetQueues()
+	} else {
+		queues, err = r.GetVhostQueues(*vhost)
+	}
+	if err != nil {
+		return output{}, err
+	}
+
+	rows := make([][]string, len(queues))
+	for i, q := range queues {
+		rows[i] = []string{
+			q.Vhost, q.Name,
+			strconv.FormatBool(q.Durable),
+			strconv.Itoa(q.Messages),
+			strconv.Itoa(q.Consumers),
+			q.Policy,
+		}
+	}
+
+	return output{
+		value:   queues,
+		headers: []string{"VHOST", "NAME", "DURABLE", "MESSAGES", "CONSUMERS", "POLICY"},
+		rows:    rows,
+	}, nil
+}
+
+func permissionsList(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 0, ""); err != nil {
+		return output{}, err
+	}
+
+	permissions, err := r.GetPermissions()
+	if err != nil {
+		return output{}, err
+	}
+
+	rows := make([][]string, len(permissions))
+	for i, p := range permissions {
+		rows[i] = []string{p.Vhost, p.User, p.Configure, p.Write, p.Read}
+	}
+
+	return output{
+		value:   permissions,
+		headers: []string{"VHOST", "USER", "CONFIGURE", "WRITE", "READ"},
+		rows:    rows,
+	}, nil
+}
+
+func permissionsSet(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 5, "<vhost> <user> <configure> <write> <read>"); err != nil {
+		return output{}, err
+	}
+
+	if err := r.CreatePermission(args[0], args[1], args[2], args[3], args[4]); err != nil {
+		return output{}, err
+	}
+
+	return changed(fmt.Sprintf("permissions of user '%s' on vhost '%s'", args[1], args[0]), "set", "set"), nil
+}
+
+func permissionsDelete(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 2, "<vhost> <user>"); err != nil {
+		return output{}, err
+	}
+
+	if err := r.DeletePermission(args[0], args[1]); err != nil {
+		return output{}, err
+	}
+
+	return changed(fmt.Sprintf("permissions of user '%s' on vhost '%s'", args[1], args[0]), "delete", "deleted"), nil
+}
+
+func topicPermissionsList(r *rabbitapi.Rabbit, args []string) (output, error) {
+	if err := needArgs(args, 0, ""); err != nil {
+		return output{}, err
+	}
+
+	permissions, err := r.GetTopicPermissions()
+	if err != nil {
+		return output{}, err
+	}
+
+	rows := make([][]string, len(permissions))
+	for i, p := range permissions {
+		rows[i] = []string{p.Vhost, p.User, p.Exchange, p.Write, p.Read}
+	}
+
+	return output{
+		value:   permissions,
+		headers: []string{"VHOST", "USER", "EXCHANGE", "WRITE", "READ"},
+		rows:    rows,
+	}, nil
+}
+
+func graph(r *rabbitapi.Rabbit, args []string) (output, error) {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	format := fs.String("format", "dot", "graph format: dot or mermaid")
+	file := fs.String("file", "", "read the topology from a definitions file instead of the broker")
+	if err := fs.Parse(args); err != nil {
+		return output{}, err
+	}
+
+	args = fs.Args()
+	if err := needArgs(args, 1, "<vhost>"); err != nil {
+		return output{}, err
+	}
+
+	var topology rabbitapi.Topology
+	var err error
+	if *file != "" {
+		topology, err = rabbitapi.LoadTopologyFile(*file)
+	} else {
+		topology, err = r.GetVhostTopology(args[0])
+	}
+	if err != nil {
+		return output{}, err
+	}
+
+	switch *format {
+	case "dot":
+		return output{message: strings.TrimSuffix(topology.DOT(args[0]), "\n")}, nil
+	case "mermaid":
+		return output{message: strings.TrimSuffix(topology.Mermaid(args[0]), "\n")}, nil
+	default:
+		return output{}, fmt.Errorf("unknown graph format '%s'", *format)
+	}
+}
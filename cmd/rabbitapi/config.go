@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+type config struct {
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Output   string `yaml:"output"`
+}
+
+// merge sets the empty fields of c from o.
+func (c *config) merge(o config) {
+	if c.URL == "" {
+		c.URL = o.URL
+	}
+	if c.Username == "" {
+		c.Username = o.Username
+	}
+	if c.Password == "" {
+		c.Password = o.Password
+	}
+	if c.Output == "" {
+		c.Output = o.Output
+	}
+}
+
+// loadConfig returns the configuration from flags, environment variables and
+// the config file, in that order of precedence. An explicitly given config
+// file must exist, the default one is optional. Empty values are left to the
+// defaults of rabbitapi.Auth.
+func loadConfig(path string, flags config) (config, error) {
+	cfg := flags
+	cfg.merge(config{
+		URL:      os.Getenv("RABBITAPI_URL"),
+		Username: os.Getenv("RABBITAPI_USERNAME"),
+		Password: os.Getenv("RABBITAPI_PASSWORD"),
+		Output:   os.Getenv("RABBITAPI_OUTPUT"),
+	})
+
+	if path == "" {
+		path = os.Getenv("RABBITAPI_CONFIG")
+	}
+
+	optional := path == ""
+	if optional {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return cfg, nil
+		}
+		path = filepath.Join(home, ".rabbitapi.yaml")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && optional {
+		return cfg, nil
+	}
+	if err != nil {
+		return config{}, err
+	}
+
+	file := config{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return config{}, fmt.Errorf("config file %s: %s", path, err)
+	}
+
+	cfg.merge(file)
+	return cfg, nil
+}
@@ -0,0 +1,139 @@
+// Command rabbitapi manages a RabbitMQ broker through the management HTTP api.
+//
+// Usage:
+//
+//	rabbitapi [flags] <command> [arguments]
+//
+// The flags are:
+//
+//	-url       management api url (env RABBITAPI_URL)
+//	-username  user name (env RABBITAPI_USERNAME)
+//	-password  password (env RABBITAPI_PASSWORD)
+//	-config    config file (env RABBITAPI_CONFIG, default ~/.rabbitapi.yaml)
+//	-output    output format: table, json or yaml (env RABBITAPI_OUTPUT)
+//	-read-only fail instead of changing the broker
+//	-dry-run   print the changing requests to stderr instead of sending them
+//
+// Flags take precedence over environment variables, which take precedence over
+// the config file. The config file is YAML (or JSON) with the keys url,
+// username, password and output.
+//
+// Run "rabbitapi help" for the list of commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/koding/rabbitapi"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "rabbitapi:", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command line args, writing the output of the command to w and
+// the requests of a dry run to stderr.
+func run(args []string, w, stderr io.Writer) error {
+	fs := flag.NewFlagSet("rabbitapi", flag.ContinueOnError)
+	flags := config{}
+	fs.StringVar(&flags.URL, "url", "", "management api url")
+	fs.StringVar(&flags.Username, "username", "", "user name")
+	fs.StringVar(&flags.Password, "password", "", "password")
+	fs.StringVar(&flags.Output, "output", "", "output format: table, json or yaml")
+	configFile := fs.String("config", "", "config file")
+	readOnly := fs.Bool("read-only", false, "fail instead of changing the broker")
+	dryRun := fs.Bool("dry-run", false, "print the changing requests to stderr instead of sending them")
+	fs.Usage = func() { usage(fs.Output()) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	args = fs.Args()
+	if len(args) == 0 || args[0] == "help" {
+		usage(w)
+		return nil
+	}
+
+	cfg, err := loadConfig(*configFile, flags)
+	if err != nil {
+		return err
+	}
+
+	// fail before sending any request, which could change the broker
+	if err := checkFormat(cfg.Output); err != nil {
+		return err
+	}
+
+	cmd, args, err := findCommand(args)
+	if err != nil {
+		return err
+	}
+
+	r := rabbitapi.Auth(cfg.Username, cfg.Password, cfg.URL)
+	if *readOnly {
+		r = r.ReadOnlyClient()
+	}
+
+	var log *rabbitapi.RequestLog
+	if *dryRun {
+		r, log = r.DryRunClient()
+	}
+
+	out, err := cmd.run(r, args)
+	if err != nil {
+		return err
+	}
+
+	if log != nil {
+		for _, req := range log.Requests() {
+			fmt.Fprintln(stderr, "dry run:", req)
+		}
+
+		if out.dryRunMessage != "" {
+			out.message = out.dryRunMessage
+		}
+	}
+
+	return write(w, cfg.Output, out)
+}
+
+// findCommand returns the command named by the first one or two arguments and
+// the remaining arguments.
+func findCommand(args []string) (command, []string, error) {
+	if len(args) > 1 {
+		if cmd, ok := commands[args[0]+" "+args[1]]; ok {
+			return cmd, args[2:], nil
+		}
+	}
+
+	if cmd, ok := commands[args[0]]; ok {
+		return cmd, args[1:], nil
+	}
+
+	return command{}, nil, fmt.Errorf("unknown command '%s', run 'rabbitapi help' for usage", strings.Join(args, " "))
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: rabbitapi [-url url] [-username name] [-password password] [-config file] [-output table|json|yaml] [-read-only] [-dry-run] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s %s\n", name, commands[name].usage)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method + " " + req.URL.EscapedPath() {
+		case "GET /api/vhosts":
+			w.Write([]byte(`[{"name":"/","tracing":false,"messages":3},{"name":"tenant","tracing":true}]`))
+		case "PUT /api/vhosts/tenant":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRun(t *testing.T) {
+	ts := testServer(t)
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"vhosts", "list"}, "NAME    TRACING  MESSAGES\n/       false    3\ntenant  true     0\n"},
+		{[]string{"-output", "json", "vhosts", "create", "tenant"}, "{\n  \"status\": \"vhost 'tenant' created\"\n}\n"},
+		{[]string{"-output", "yaml", "vhosts", "create", "tenant"}, "status: vhost 'tenant' created\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := run(append([]string{"-url", ts.URL}, test.args...), &buf, io.Discard); err != nil {
+			t.Fatalf("%v: %s", test.args, err)
+		}
+
+		if buf.String() != test.want {
+			t.Errorf("%v: got %q, want %q", test.args, buf.String(), test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := run([]string{"-url", ts.URL, "vhosts", "frobnicate"}, &buf, io.Discard); err == nil {
+		t.Error("unknown command: expected an error")
+	}
+}
+
+func TestRunUnknownOutput(t *testing.T) {
+	// any request fails the test, the format must be rejected before
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}))
+	defer ts.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	var buf bytes.Buffer
+	err := run([]string{"-url", ts.URL, "-output", "xml", "vhosts", "create", "tenant"}, &buf, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "unknown output format") {
+		t.Errorf("expected an unknown output format error, got %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestRunReadOnly(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}))
+	defer ts.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	var buf bytes.Buffer
+	if err := run([]string{"-url", ts.URL, "-read-only", "vhosts", "create", "tenant"}, &buf, io.Discard); err == nil {
+		t.Error("read-only: expected an error")
+	}
+
+	buf.Reset()
+	var stderr bytes.Buffer
+	if err := run([]string{"-url", ts.URL, "-dry-run", "-output", "json", "vhosts", "delete", "tenant"}, &buf, &stderr); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "{\n  \"status\": \"would delete vhost 'tenant'\"\n}\n"; buf.String() != want {
+		t.Errorf("dry run: unexpected output %q", buf.String())
+	}
+	if want := "dry run: DELETE /api/vhosts/tenant\n"; stderr.String() != want {
+		t.Errorf("dry run: unexpected stderr %q", stderr.String())
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte("url: http://file\nusername: fileuser\noutput: yaml\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("RABBITAPI_URL", "")
+	t.Setenv("RABBITAPI_USERNAME", "envuser")
+	t.Setenv("RABBITAPI_PASSWORD", "")
+	t.Setenv("RABBITAPI_OUTPUT", "")
+
+	cfg, err := loadConfig(path, config{Output: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := config{URL: "http://file", Username: "envuser", Output: "json"}
+	if cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+
+	if _, err := loadConfig(path+".missing", config{}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("missing explicit config file: got error %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// output is the result of a command. value is printed in the json and yaml
+// formats, headers and rows in the table format. Commands which don't return
+// anything set message instead, and dryRunMessage if they change the broker.
+type output struct {
+	value         interface{}
+	headers       []string
+	rows          [][]string
+	message       string
+	dryRunMessage string
+}
+
+// changed returns the output of a command which changed object, e.g.
+// "vhost 'tenant' created", or "would create vhost 'tenant'" in a dry run.
+func changed(object, verb, participle string) output {
+	return output{
+		message:       object + " " + participle,
+		dryRunMessage: "would " + verb + " " + object,
+	}
+}
+
+// checkFormat returns an error if format is not an output format.
+func checkFormat(format string) error {
+	switch format {
+	case "", "table", "json", "yaml":
+		return nil
+	default:
+		return fmt.Errorf("unknown output format '%s'", format)
+	}
+}
+
+func write(w io.Writer, format string, out output) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	if out.message != "" {
+		if format == "json" || format == "yaml" {
+			out.value = map[string]string{"status": out.message}
+		} else {
+			_, err := fmt.Fprintln(w, out.message)
+			return err
+		}
+	}
+
+	switch format {
+	case "", "table":
+		return writeTable(w, out.headers, out.rows)
+	case "json":
+		data, err := json.MarshalIndent(out.value, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "yaml":
+		// go through json, so that yaml keys are the api field names
+		data, err := json.Marshal(out.value)
+		if err != nil {
+			return err
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+
+		data, err = yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	return nil
+}
+
+func writeTable(w io.Writer, headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,70 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+type Connection struct {
+	Channels         int                    `json:"channels"`
+	ClientProperties map[string]interface{} `json:"client_properties"`
+	Host             string                 `json:"host"`
+	Name             string                 `json:"name"`
+	Node             string                 `json:"node"`
+	PeerHost         string                 `json:"peer_host"`
+	PeerPort         int                    `json:"peer_port"`
+	Port             int                    `json:"port"`
+	Protocol         string                 `json:"protocol"`
+	RecvCnt          int64                  `json:"recv_cnt"`
+	RecvOct          int64                  `json:"recv_oct"`
+	RecvOctDetails   RateDetails            `json:"recv_oct_details"`
+	SendCnt          int64                  `json:"send_cnt"`
+	SendOct          int64                  `json:"send_oct"`
+	SendOctDetails   RateDetails            `json:"send_oct_details"`
+	State            string                 `json:"state"`
+	User             string                 `json:"user"`
+	Vhost            string                 `json:"vhost"`
+}
+
+// GetConnections returns a list of all open connections.
+func (r *Rabbit) GetConnections() ([]Connection, error) {
+	body, err := r.doRequest("GET", "/api/connections", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	connections := make([]Connection, 0)
+	err = json.Unmarshal(body, &connections)
+	if err != nil {
+		return nil, err
+	}
+
+	return connections, nil
+}
+
+// GetConnection returns an individual connection. Samples of its data rates
+// can be requested with StatsOptions.
+func (r *Rabbit) GetConnection(name string, opts ...StatsOptions) (Connection, error) {
+	body, err := r.doRequest("GET", "/api/connections/"+url.PathEscape(name)+statsQuery(opts), nil)
+	if err != nil {
+		return Connection{}, err
+	}
+
+	connection := Connection{}
+	err = json.Unmarshal(body, &connection)
+	if err != nil {
+		return Connection{}, err
+	}
+
+	return connection, nil
+}
+
+// CloseConnection closes an individual connection.
+func (r *Rabbit) CloseConnection(name string) error {
+	_, err := r.doRequest("DELETE", "/api/connections/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package rabbitapi
+
+import (
+	"testing"
+)
+
+func TestRabbit_GetConnections(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	connections, err := r.GetConnections()
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("connections:", connections)
+	}
+}
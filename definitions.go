@@ -0,0 +1,44 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+)
+
+// Definitions are the objects defined on a broker, as returned by
+// GetDefinitions or loaded from an exported definitions file with
+// LoadTopologyFile. They have the same shape as a Topology.
+type Definitions = Topology
+
+// GetDefinitions returns the vhosts, users, permissions, exchanges, queues,
+// bindings and policies of the broker in a single request, so that they are
+// a consistent snapshot. Users include their password hashes.
+func (r *Rabbit) GetDefinitions() (Definitions, error) {
+	spec, err := r.definitions()
+	if err != nil {
+		return Definitions{}, err
+	}
+
+	return spec.topology(), nil
+}
+
+// definitions returns the definitions of the broker with its version.
+func (r *Rabbit) definitions() (topologySpec, error) {
+	body, err := r.doRequest("GET", "/api/definitions", nil)
+	if err != nil {
+		return topologySpec{}, err
+	}
+
+	spec := topologySpec{}
+	err = json.Unmarshal(body, &spec)
+	return spec, err
+}
+
+// vhostDefinitions returns the definitions of the vhost as exported by the
+// broker. Needs RabbitMQ 3.7 or later.
+func (r *Rabbit) vhostDefinitions(vhost string) ([]byte, error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	return r.doRequest("GET", "/api/definitions/"+vhost, nil)
+}
@@ -0,0 +1,228 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type DiffType string
+
+const (
+	DiffAdded   DiffType = "added"
+	DiffRemoved DiffType = "removed"
+	DiffChanged DiffType = "changed"
+)
+
+// Difference is an object that differs between two Definitions.
+type Difference struct {
+	Type  DiffType
+	Kind  string // vhost, user, permission, exchange, queue, binding or policy
+	Vhost string
+	Name  string
+
+	// Fields are the changed fields of a changed object, named like the api
+	// fields. They are empty for added and removed objects.
+	Fields []FieldDiff
+}
+
+// FieldDiff is a field with a different value in both Definitions.
+type FieldDiff struct {
+	Field string
+	A, B  interface{}
+}
+
+func (f FieldDiff) String() string {
+	if f.Field == "password_hash" {
+		return "password_hash changed" // don't print secrets
+	}
+
+	return fmt.Sprintf("%s %v -> %v", f.Field, f.A, f.B)
+}
+
+func (d Difference) String() string {
+	return describeDifference(string(d.Type), d.Kind, d.Vhost, d.Name, d.Fields)
+}
+
+func describeDifference(action, kind, vhost, name string, fields []FieldDiff) string {
+	s := fmt.Sprintf("%s %s %s", action, kind, name)
+	if vhost != "" {
+		s += " in vhost " + vhost
+	}
+
+	if len(fields) != 0 {
+		changes := make([]string, len(fields))
+		for i, f := range fields {
+			changes[i] = f.String()
+		}
+		s += ": " + strings.Join(changes, ", ")
+	}
+
+	return s
+}
+
+// diffObject is an object of any kind, reduced to what Diff compares.
+type diffObject struct {
+	kind   string
+	vhost  string
+	name   string
+	fields []diffField
+}
+
+type diffField struct {
+	name  string
+	value interface{}
+}
+
+// DiffOptions configure Diff.
+type DiffOptions struct {
+	// PasswordHashes compares the password_hash of users. Hashes are
+	// salted, so the same password set on two brokers separately always
+	// differs; compare them only for definitions of the same broker.
+	PasswordHashes bool
+}
+
+// Diff compares the definitions a and b, e.g. of a staging and a production
+// broker, and returns the objects added in b, removed from b and changed
+// between them, sorted by kind, vhost and name. Only the properties objects
+// are declared with are compared, not their statistics. Bindings have no
+// properties of their own, a binding with other arguments is a different
+// binding. Password hashes are not compared unless the optional options ask
+// for it.
+func Diff(a, b Definitions, opts ...DiffOptions) []Difference {
+	o := DiffOptions{}
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+
+	objectsA := diffObjects(a, o)
+	objectsB := diffObjects(b, o)
+
+	diffs := make([]Difference, 0)
+	for key, objA := range objectsA {
+		objB, ok := objectsB[key]
+		if !ok {
+			diffs = append(diffs, Difference{Type: DiffRemoved, Kind: objA.kind, Vhost: objA.vhost, Name: objA.name})
+			continue
+		}
+
+		fields := make([]FieldDiff, 0)
+		for i, f := range objA.fields {
+			if !diffEqual(f.value, objB.fields[i].value) {
+				fields = append(fields, FieldDiff{Field: f.name, A: f.value, B: objB.fields[i].value})
+			}
+		}
+
+		if len(fields) != 0 {
+			diffs = append(diffs, Difference{Type: DiffChanged, Kind: objA.kind, Vhost: objA.vhost, Name: objA.name, Fields: fields})
+		}
+	}
+
+	for key, objB := range objectsB {
+		if _, ok := objectsA[key]; !ok {
+			diffs = append(diffs, Difference{Type: DiffAdded, Kind: objB.kind, Vhost: objB.vhost, Name: objB.name})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		a, b := diffs[i], diffs[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		if a.Vhost != b.Vhost {
+			return a.Vhost < b.Vhost
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Type < b.Type
+	})
+
+	return diffs
+}
+
+// diffObjects returns the objects of d keyed by kind and identity.
+func diffObjects(d Definitions, opts DiffOptions) map[string]diffObject {
+	objects := make(map[string]diffObject)
+	add := func(obj diffObject, key string) {
+		objects[obj.kind+"\x00"+key] = obj
+	}
+
+	for _, v := range d.Vhosts {
+		add(diffObject{kind: "vhost", name: v.Name, fields: []diffField{
+			{"tracing", v.Tracing},
+		}}, v.Name)
+	}
+
+	for _, u := range d.Users {
+		fields := []diffField{
+			{"hashing_algorithm", u.HashingAlgorithm},
+			{"tags", u.Tags},
+		}
+		if opts.PasswordHashes {
+			fields = append([]diffField{{"password_hash", u.PasswordHash}}, fields...)
+		}
+
+		add(diffObject{kind: "user", name: u.Name, fields: fields}, u.Name)
+	}
+
+	for _, p := range d.Permissions {
+		add(diffObject{kind: "permission", vhost: p.Vhost, name: p.User, fields: []diffField{
+			{"configure", p.Configure},
+			{"write", p.Write},
+			{"read", p.Read},
+		}}, p.Vhost+"\x00"+p.User)
+	}
+
+	for _, e := range d.Exchanges {
+		add(diffObject{kind: "exchange", vhost: e.Vhost, name: e.Name, fields: []diffField{
+			{"type", e.Type},
+			{"durable", e.Durable},
+			{"auto_delete", e.AutoDelete},
+			{"internal", e.Internal},
+			{"arguments", e.Arguments},
+		}}, e.Vhost+"\x00"+e.Name)
+	}
+
+	for _, q := range d.Queues {
+		add(diffObject{kind: "queue", vhost: q.Vhost, name: q.Name, fields: []diffField{
+			{"durable", q.Durable},
+			{"auto_delete", q.AutoDelete},
+			{"arguments", q.Arguments},
+		}}, q.Vhost+"\x00"+q.Name)
+	}
+
+	for _, b := range d.Bindings {
+		if b.DestinationType == "" {
+			b.DestinationType = DestinationQueue
+		}
+		add(diffObject{kind: "binding", vhost: b.Vhost, name: bindingName(b)}, bindingKey(b))
+	}
+
+	for _, p := range d.Policies {
+		applyTo := p.ApplyTo
+		if applyTo == "" {
+			applyTo = "all"
+		}
+
+		add(diffObject{kind: "policy", vhost: p.Vhost, name: p.Name, fields: []diffField{
+			{"pattern", p.Pattern},
+			{"apply-to", applyTo},
+			{"priority", p.Priority},
+			{"definition", p.Definition},
+		}}, p.Vhost+"\x00"+p.Name)
+	}
+
+	return objects
+}
+
+func diffEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		return argumentsKey(a) == argumentsKey(b.(map[string]interface{}))
+	case Tags:
+		return a.equal(b.(Tags))
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,98 @@
+package rabbitapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRabbit_Diff(t *testing.T) {
+	a := Definitions{
+		Vhosts: []Vhost{{Name: "tenant"}, {Name: "old"}},
+		Users:  []User{{Name: "app", PasswordHash: "x", Tags: Tags{"monitoring", "management"}}},
+		Exchanges: []Exchange{
+			{Vhost: "tenant", Name: "events", Type: "topic", Durable: true},
+			{Vhost: "tenant", Name: "audit", Type: "fanout", Arguments: map[string]interface{}{}},
+		},
+		Queues: []Queue{{Vhost: "tenant", Name: "jobs", Durable: true, Messages: 10}},
+		Bindings: []Binding{
+			{Vhost: "tenant", Source: "events", Destination: "jobs", DestinationType: DestinationQueue, RoutingKey: "jobs.#"},
+		},
+		Policies: []Policy{{Vhost: "tenant", Name: "ttl", Pattern: "^jobs$", Definition: map[string]interface{}{"message-ttl": 60000}}},
+	}
+
+	b := Definitions{
+		Vhosts: []Vhost{{Name: "tenant"}, {Name: "new"}},
+		Users:  []User{{Name: "app", PasswordHash: "y", Tags: Tags{"management", "monitoring"}}},
+		Exchanges: []Exchange{
+			{Vhost: "tenant", Name: "events", Type: "topic", Durable: false},
+			{Vhost: "tenant", Name: "audit", Type: "fanout"},
+		},
+		Queues: []Queue{{Vhost: "tenant", Name: "jobs", Durable: true}},
+		Bindings: []Binding{
+			{Vhost: "tenant", Source: "events", Destination: "jobs", RoutingKey: "jobs.*"},
+		},
+		Policies: []Policy{{Vhost: "tenant", Name: "ttl", Pattern: "^jobs$", ApplyTo: "all", Definition: map[string]interface{}{"message-ttl": float64(30000)}}},
+	}
+
+	want := []string{
+		"added vhost new",
+		"removed vhost old",
+		"changed exchange events in vhost tenant: durable true -> false",
+		`removed binding events -> queue jobs ("jobs.#") in vhost tenant`,
+		`added binding events -> queue jobs ("jobs.*") in vhost tenant`,
+		"changed policy ttl in vhost tenant: definition map[message-ttl:60000] -> map[message-ttl:30000]",
+	}
+
+	diffs := Diff(a, b)
+	got := make([]string, len(diffs))
+	for i, d := range diffs {
+		got[i] = d.String()
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+
+	if diffs := Diff(a, a); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+
+	diffs = Diff(a, b, DiffOptions{PasswordHashes: true})
+	if len(diffs) != len(want)+1 || diffs[2].String() != "changed user app: password_hash changed" {
+		t.Errorf("expected a changed password_hash, got %v", diffs)
+	}
+}
+
+func TestRabbit_GetDefinitions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/definitions" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		w.Write([]byte(`{
+			"rabbit_version": "3.12.0",
+			"users": [{"name": "app", "password_hash": "abc", "hashing_algorithm": "rabbit_password_hashing_sha256", "tags": ["monitoring"]}],
+			"vhosts": [{"name": "/"}],
+			"permissions": [{"user": "app", "vhost": "/", "configure": ".*", "write": ".*", "read": ".*"}],
+			"exchanges": [{"name": "events", "vhost": "/", "type": "topic", "durable": true, "auto_delete": false, "internal": false, "arguments": {}}],
+			"queues": [],
+			"bindings": [],
+			"policies": []
+		}`))
+	}))
+	defer ts.Close()
+
+	d, err := Auth("guest", "guest", ts.URL).GetDefinitions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Users) != 1 || d.Users[0].PasswordHash != "abc" || !d.Users[0].Tags.Has(TagMonitoring) {
+		t.Errorf("unexpected users %+v", d.Users)
+	}
+
+	if len(d.Exchanges) != 1 || d.Exchanges[0].Type != "topic" || len(d.Permissions) != 1 {
+		t.Errorf("unexpected definitions %+v", d)
+	}
+}
@@ -5,11 +5,31 @@ Currently supported api calls are:
 
     GET     /api/overview
 
+    GET     /api/nodes
+    GET     /api/nodes/name
+
+    GET     /api/definitions
+    GET     /api/definitions/vhost
+
     GET     /api/exchanges
     GET     /api/exchanges/vhost/name
     PUT     /api/exchanges/vhost/name
     DELETE  /api/exchanges/vhost/name
     GET     /api/exchanges/vhost/name/bindings/source
+    GET     /api/exchanges/vhost/name/bindings/destination
+
+    GET     /api/queues
+    GET     /api/queues/vhost
+    GET     /api/queues/vhost/name
+    PUT     /api/queues/vhost/name
+    DELETE  /api/queues/vhost/name
+
+    GET     /api/bindings
+    GET     /api/bindings/vhost
+    POST    /api/bindings/vhost/e/exchange/q/queue
+    POST    /api/bindings/vhost/e/source/e/destination
+    DELETE  /api/bindings/vhost/e/exchange/q/queue/props
+    DELETE  /api/bindings/vhost/e/source/e/destination/props
 
     GET     /api/vhosts
     GET     /api/vhosts/name
@@ -17,19 +37,128 @@ Currently supported api calls are:
     DELETE  /api/vhosts/name
     GET     /api/vhosts/name/permissions
 
+    GET     /api/vhost-limits/vhost
+    PUT     /api/vhost-limits/vhost/name
+    DELETE  /api/vhost-limits/vhost/name
+
     GET     /api/users
     GET     /api/users/name
     PUT     /api/users/name
     DELETE  /api/users/name
     GET     /api/users/name/permissions
 
+    GET     /api/whoami
+
+    GET     /api/connections
+    GET     /api/connections/name
+    DELETE  /api/connections/name
+
     GET     /api/permissions
     GET     /api/permissions/vhost/user
     PUT     /api/permissions/vhost/user
     DELETE  /api/permissions/vhost/user
 
+    GET     /api/topic-permissions
+
+    GET     /api/policies
+    GET     /api/policies/vhost
+    GET     /api/policies/vhost/name
+    PUT     /api/policies/vhost/name
+    DELETE  /api/policies/vhost/name
+
     GET     /api/aliveness-test/vhost
 
+Credentials can be checked at startup, before any other call is made:
+
+	if err := r.RequireAdministrator(); err != nil {
+		log.Fatal(err) // e.g. credentials of user 'app' lack the administrator tag
+	}
+
+Idempotent requests can be retried on transient failures by setting a
+RetryPolicy:
+
+	r.Retry = &rabbitapi.RetryPolicy{MaxAttempts: 5}
+
+Hooks are called after every request, with the method, endpoint template
+(e.g. /api/exchanges/{vhost}/{name}), status and duration. Packages promhook
+and otelhook turn them into Prometheus metrics and OpenTelemetry spans:
+
+	r.Hooks = append(r.Hooks, rabbitapi.HookFunc(func(info rabbitapi.RequestInfo) {
+		log.Println(info.Method, info.Endpoint, info.StatusCode, info.Duration)
+	}))
+
+ReadOnlyClient returns a client whose PUT, POST and DELETE requests, and the
+aliveness test which declares a queue, fail with ErrReadOnly without touching
+the network. It guards against mistakes; only a broker user without write
+permissions guarantees that nothing is changed. DryRunClient returns a client
+which records the PUT, POST and DELETE requests instead of sending them:
+
+	dry, log := r.DryRunClient()
+	report, err := dry.ProvisionTenant(tenant, false)
+	for _, req := range log.Requests() {
+		fmt.Println(req) // e.g. PUT /api/vhosts/tenant
+	}
+
+A whole topology can be declared and applied with Reconcile. Plan returns the
+changes without applying them. Exchanges and queues whose properties differ
+are only deleted and declared again with ReconcileOptions.Recreate:
+
+	changes, err := r.Plan(ctx, rabbitapi.Topology{
+		Vhosts:    []rabbitapi.Vhost{{Name: "tenant"}},
+		Exchanges: []rabbitapi.Exchange{{Vhost: "tenant", Name: "events", Type: "topic"}},
+	}, rabbitapi.ReconcileOptions{})
+
+Topologies can also be loaded from a versioned YAML or JSON spec file, which
+is validated before use:
+
+	topology, err := rabbitapi.LoadTopologyFile("topology.yaml")
+
+The routing topology of a vhost, from the broker or from a definitions file,
+can be drawn as a Graphviz or Mermaid diagram:
+
+	topology, err := r.GetVhostTopology("tenant")
+	fmt.Print(topology.DOT("tenant")) // or topology.Mermaid("tenant")
+
+Diff compares the definitions of two brokers to find drift:
+
+	a, err := staging.GetDefinitions()
+	b, err := production.GetDefinitions()
+	for _, d := range rabbitapi.Diff(a, b) {
+		fmt.Println(d) // e.g. changed exchange events in vhost tenant: durable true -> false
+	}
+
+Migrate copies the definitions of one broker to another. Run it with DryRun
+first to see what would change:
+
+	report, err := rabbitapi.Migrate(old, new, rabbitapi.MigrateOptions{DryRun: true})
+	fmt.Println(report)
+
+ProvisionTenant creates a tenant's vhost, user, permissions, limits and
+exchanges, deleting them again if any step fails:
+
+	report, err := r.ProvisionTenant(rabbitapi.Tenant{
+		Vhost: "tenant", User: "app", Password: "secret",
+		Configure: ".*", Write: ".*", Read: ".*",
+		Limits:    map[string]int{rabbitapi.LimitMaxConnections: 100},
+		Exchanges: map[string]rabbitapi.ExchangeOptions{"events": {Type: rabbitapi.ExchangeTopic}},
+	}, false)
+
+DeprovisionTenant deletes it again, refusing to do so while the vhost is in
+use unless told otherwise:
+
+	report, err := r.DeprovisionTenant("tenant", rabbitapi.DeprovisionOptions{
+		CloseConnections: true,
+		Backup:           backupFile,
+	})
+
+Watch polls the broker and reports changes, e.g. objects added by hand on
+the management UI:
+
+	events, err := r.Watch(ctx, time.Minute)
+	for e := range events {
+		fmt.Println(e) // e.g. created exchange events in vhost tenant
+	}
+
 Example code:
 
 	r := rabbitapi.Auth("guest", "guest", "http://localhost:15672")
@@ -40,7 +169,7 @@ Example code:
 		fmt.Println("vhosts:", vhosts)
 	}
 
-	err = r.CreateExchange("/", "rabbitapi", "topic", false, false, false, nil)
+	err = r.CreateExchange("/", "rabbitapi", rabbitapi.ExchangeOptions{Type: rabbitapi.ExchangeTopic})
 	if err != nil {
 		fmt.Println(err)
 	}
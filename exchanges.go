@@ -2,28 +2,140 @@ package rabbitapi
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 )
 
+type ExchangeType string
+
+const (
+	ExchangeDirect         ExchangeType = "direct"
+	ExchangeFanout         ExchangeType = "fanout"
+	ExchangeTopic          ExchangeType = "topic"
+	ExchangeHeaders        ExchangeType = "headers"
+	ExchangeDelayedMessage ExchangeType = "x-delayed-message" // rabbitmq_delayed_message_exchange plugin
+	ExchangeConsistentHash ExchangeType = "x-consistent-hash" // rabbitmq_consistent_hash_exchange plugin
+)
+
+// Valid reports whether t is a built-in exchange type or a plugin type, whose
+// names start with "x-" (e.g. x-random or x-recent-history).
+func (t ExchangeType) Valid() bool {
+	switch t {
+	case ExchangeDirect, ExchangeFanout, ExchangeTopic, ExchangeHeaders:
+		return true
+	}
+
+	return strings.HasPrefix(string(t), "x-") && len(t) > len("x-")
+}
+
 type Exchange struct {
-	Arguments  map[string]interface{} `json:"arguments"`
-	AutoDelete bool                   `json:"auto_delete"`
-	Durable    bool                   `json:"durable"`
-	Internal   bool                   `json:"durable"`
-	Name       string                 `json:"name"`
-	Type       string                 `json:"type"`
-	Vhost      string                 `json:"vhost"`
+	Arguments    map[string]interface{} `json:"arguments"`
+	AutoDelete   bool                   `json:"auto_delete"`
+	Durable      bool                   `json:"durable"`
+	Internal     bool                   `json:"internal"`
+	MessageStats MessageStats           `json:"message_stats"`
+	Name         string                 `json:"name"`
+	Policy       string                 `json:"policy,omitempty"`
+	Type         string                 `json:"type"`
+	Vhost        string                 `json:"vhost"`
+}
+
+// Options returns the options the exchange was declared with.
+func (e Exchange) Options() ExchangeOptions {
+	opts := ExchangeOptions{
+		Type:       ExchangeType(e.Type),
+		Durable:    e.Durable,
+		AutoDelete: e.AutoDelete,
+		Internal:   e.Internal,
+		Arguments:  make(map[string]interface{}, len(e.Arguments)),
+	}
+
+	for key, value := range e.Arguments {
+		switch key {
+		case "alternate-exchange":
+			opts.AlternateExchange, _ = value.(string)
+		case "x-delayed-type":
+			delayedType, _ := value.(string)
+			opts.DelayedType = ExchangeType(delayedType)
+		default:
+			opts.Arguments[key] = value
+		}
+	}
+
+	return opts
+}
+
+// ExchangeOptions are the properties of an exchange passed to CreateExchange.
+type ExchangeOptions struct {
+	Type       ExchangeType
+	Durable    bool
+	AutoDelete bool
+	Internal   bool
+
+	// AlternateExchange receives the messages the exchange can't route.
+	AlternateExchange string
+
+	// DelayedType is the routing type of an ExchangeDelayedMessage exchange
+	// and is required for it.
+	DelayedType ExchangeType
+
+	// Arguments are additional, type specific arguments.
+	Arguments map[string]interface{}
+}
+
+// Validate checks the options for unknown types, and the arguments of the
+// plugin types this package knows (x-delayed-message and x-consistent-hash).
+// Other plugin types are accepted with any arguments.
+func (o ExchangeOptions) Validate() error {
+	if !o.Type.Valid() {
+		return fmt.Errorf("unknown exchange type '%s'", o.Type)
+	}
+
+	args := o.arguments()
+	delayedType, _ := args["x-delayed-type"].(string)
+
+	switch o.Type {
+	case ExchangeDelayedMessage:
+		if t := ExchangeType(delayedType); !t.Valid() || t == ExchangeDelayedMessage {
+			return fmt.Errorf("invalid delayed type '%s' for a %s exchange", delayedType, o.Type)
+		}
+	case ExchangeConsistentHash:
+		if args["hash-header"] != nil && args["hash-property"] != nil {
+			return fmt.Errorf("a %s exchange hashes either a header or a property, not both", o.Type)
+		}
+	}
+
+	if o.Type != ExchangeDelayedMessage && delayedType != "" {
+		return fmt.Errorf("delayed type is only supported by %s exchanges", ExchangeDelayedMessage)
+	}
+
+	return nil
 }
 
-type ExchangeSource struct {
-	Arguments       map[string]interface{} `json:"arguments"`
-	Destination     string                 `json:"destination"`
-	DestinationType string                 `json:"destination_type"`
-	PropertiesKey   string                 `json:"properties_key"`
-	RoutingKey      string                 `json:"routing_key"`
-	Source          string                 `json:"source"`
-	Vhost           string                 `json:"vhost"`
+// arguments returns the arguments sent to the broker, including the
+// alternate exchange and delayed type.
+func (o ExchangeOptions) arguments() map[string]interface{} {
+	args := make(map[string]interface{}, len(o.Arguments)+2)
+	for key, value := range o.Arguments {
+		args[key] = value
+	}
+
+	if o.AlternateExchange != "" {
+		args["alternate-exchange"] = o.AlternateExchange
+	}
+
+	if o.DelayedType != "" {
+		args["x-delayed-type"] = string(o.DelayedType)
+	}
+
+	return args
 }
 
+// ExchangeSource is the former name of Binding.
+//
+// Deprecated: use Binding.
+type ExchangeSource = Binding
+
 // GetExchanges() returns a list of all exchanges.
 func (r *Rabbit) GetExchanges() ([]Exchange, error) {
 	body, err := r.doRequest("GET", "/api/exchanges", nil)
@@ -61,12 +173,13 @@ func (r *Rabbit) GetVhostExchanges(vhost string) ([]Exchange, error) {
 }
 
 // GetExchange returns an individual exchange for the given vhost and name.
-func (r *Rabbit) GetExchange(vhost, name string) (Exchange, error) {
+// Samples of its message rates can be requested with StatsOptions.
+func (r *Rabbit) GetExchange(vhost, name string, opts ...StatsOptions) (Exchange, error) {
 	if vhost == "/" {
 		vhost = "%2f"
 	}
 
-	body, err := r.doRequest("GET", "/api/exchanges/"+vhost+"/"+name, nil)
+	body, err := r.doRequest("GET", "/api/exchanges/"+vhost+"/"+name+statsQuery(opts), nil)
 	if err != nil {
 		return Exchange{}, err
 	}
@@ -80,22 +193,30 @@ func (r *Rabbit) GetExchange(vhost, name string) (Exchange, error) {
 	return exchange, nil
 }
 
-// CreateExchange creates an invididual exchange with for the given vhost and name.
-func (r *Rabbit) CreateExchange(vhost, name, kind string, durable, autoDelete, internal bool, args map[string]interface{}) error {
+// CreateExchange creates an invididual exchange with for the given vhost and
+// name. The options are validated before the request is sent. Declaring an
+// exchange which already exists with the same options does nothing; exchanges
+// with different options can't be updated in place and need to be deleted
+// first.
+func (r *Rabbit) CreateExchange(vhost, name string, opts ExchangeOptions) error {
 	if vhost == "/" {
 		vhost = "%2f"
 	}
 
-	if args == nil {
-		args = make(map[string]interface{}, 0)
+	if name == "" || strings.HasPrefix(name, "amq.") {
+		return fmt.Errorf("exchange name '%s' is reserved", name)
+	}
+
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
-	exchange := &Exchange{
-		Type:       kind,
-		Durable:    durable,
-		AutoDelete: autoDelete,
-		Internal:   internal,
-		Arguments:  args,
+	exchange := map[string]interface{}{
+		"type":        opts.Type,
+		"durable":     opts.Durable,
+		"auto_delete": opts.AutoDelete,
+		"internal":    opts.Internal,
+		"arguments":   opts.arguments(),
 	}
 
 	data, err := json.Marshal(exchange)
@@ -127,7 +248,7 @@ func (r *Rabbit) DeleteExchange(vhost, name string) error {
 
 // GetExchangeSource returns a list of all bindings in which a given exchange
 // is the source.
-func (r *Rabbit) GetExchangeSource(vhost, name string) ([]ExchangeSource, error) {
+func (r *Rabbit) GetExchangeSource(vhost, name string) ([]Binding, error) {
 	if vhost == "/" {
 		vhost = "%2f"
 	}
@@ -138,11 +259,32 @@ func (r *Rabbit) GetExchangeSource(vhost, name string) ([]ExchangeSource, error)
 		return nil, err
 	}
 
-	exchangeSources := make([]ExchangeSource, 0)
-	err = json.Unmarshal(body, &exchangeSources)
+	bindings := make([]Binding, 0)
+	err = json.Unmarshal(body, &bindings)
+	if err != nil {
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+// GetExchangeDestination returns a list of all bindings in which a given
+// exchange is the destination.
+func (r *Rabbit) GetExchangeDestination(vhost, name string) ([]Binding, error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	body, err := r.doRequest("GET", "/api/exchanges/"+vhost+"/"+name+"/bindings/destination", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings := make([]Binding, 0)
+	err = json.Unmarshal(body, &bindings)
 	if err != nil {
 		return nil, err
 	}
 
-	return exchangeSources, nil
+	return bindings, nil
 }
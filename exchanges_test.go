@@ -1,6 +1,7 @@
 package rabbitapi
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -26,7 +27,7 @@ func TestRabbit_GetVhostExchanges(t *testing.T) {
 
 func TestRabbit_CreateExchange(t *testing.T) {
 	r := Auth("guest", "guest", "http://localhost:15672")
-	err := r.CreateExchange("/", "rabbitapi", "topic", false, true, false, nil)
+	err := r.CreateExchange("/", "rabbitapi", ExchangeOptions{Type: ExchangeTopic, AutoDelete: true})
 	if err != nil {
 		t.Error(err)
 	} else {
@@ -71,3 +72,57 @@ func TestRabbit_GetExchangeSources(t *testing.T) {
 		t.Log("bindings which the exchange 'amq.default' is the source:", sources)
 	}
 }
+
+func TestRabbit_DecodeExchange(t *testing.T) {
+	data := `{"name":"events","vhost":"/","type":"topic","durable":true,"auto_delete":false,"internal":true,
+		"arguments":{"alternate-exchange":"unrouted"},"policy":"ha","message_stats":{"publish_in":12}}`
+
+	exchange := Exchange{}
+	if err := json.Unmarshal([]byte(data), &exchange); err != nil {
+		t.Fatal(err)
+	}
+
+	if !exchange.Durable || !exchange.Internal || exchange.Policy != "ha" || exchange.MessageStats.PublishIn != 12 {
+		t.Errorf("unexpected exchange %+v", exchange)
+	}
+
+	opts := exchange.Options()
+	if opts.Type != ExchangeTopic || opts.AlternateExchange != "unrouted" || len(opts.Arguments) != 0 {
+		t.Errorf("unexpected options %+v", opts)
+	}
+}
+
+func TestRabbit_ExchangeOptionsValidate(t *testing.T) {
+	tests := []struct {
+		opts  ExchangeOptions
+		valid bool
+	}{
+		{ExchangeOptions{Type: ExchangeTopic}, true},
+		{ExchangeOptions{Type: "tpoic"}, false},
+		{ExchangeOptions{Type: ExchangeDelayedMessage, DelayedType: ExchangeDirect}, true},
+		{ExchangeOptions{Type: ExchangeDelayedMessage}, false},
+		{ExchangeOptions{Type: ExchangeFanout, DelayedType: ExchangeDirect}, false},
+		{ExchangeOptions{Type: ExchangeConsistentHash}, true},
+		{ExchangeOptions{Type: ExchangeConsistentHash, Arguments: map[string]interface{}{"hash-header": "h", "hash-property": "message_id"}}, false},
+		{ExchangeOptions{Type: ExchangeDelayedMessage, Arguments: map[string]interface{}{"x-delayed-type": "topic"}}, true},
+		{ExchangeOptions{Type: "x-random"}, true},
+		{ExchangeOptions{Type: "x-recent-history", Arguments: map[string]interface{}{"x-recent-history-length": 10}}, true},
+		{ExchangeOptions{Type: "x-"}, false},
+	}
+
+	for i, test := range tests {
+		if err := test.opts.Validate(); (err == nil) != test.valid {
+			t.Errorf("options %d: expected valid=%v, got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestRabbit_GetExchangeDestination(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	destinations, err := r.GetExchangeDestination("/", "amq.topic")
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("bindings which the exchange 'amq.topic' is the destination:", destinations)
+	}
+}
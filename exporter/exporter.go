@@ -0,0 +1,112 @@
+// Package exporter exposes broker metrics read through the management api in
+// the Prometheus text format, for brokers without the rabbitmq_prometheus
+// plugin.
+//
+//	e, err := exporter.New(rabbitapi.Auth("monitoring", "secret", "http://localhost:15672"), 15*time.Second)
+//	go e.Run(ctx)
+//	http.Handle("/metrics", e)
+//
+// Every interval the exporter calls GetOverview, GetNodes, GetVhosts and
+// StreamQueues; scrapes are served from the last result, so they never hit the
+// broker. The queues are streamed so that their JSON list is not buffered, but
+// the last result holds samples for every queue, so memory still grows with
+// the number of queues. The user needs the monitoring tag.
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koding/rabbitapi"
+)
+
+const (
+	textContentType        = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// Exporter collects metrics from a broker and serves them over HTTP.
+type Exporter struct {
+	r        *rabbitapi.Rabbit
+	interval time.Duration
+
+	mu       sync.RWMutex
+	families []family
+}
+
+// New returns an exporter collecting from r every interval, which must be
+// positive. Nothing is collected until Run or Collect is called.
+func New(r *rabbitapi.Rabbit, interval time.Duration) (*Exporter, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid collection interval %s", interval)
+	}
+
+	return &Exporter{r: r, interval: interval}, nil
+}
+
+// Run collects metrics right away and then every interval until ctx is done.
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.Collect()
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Collect reads the metrics from the broker once. If that fails, the
+// rabbitmq_up metric is 0 and the error is returned; the other metrics are
+// dropped rather than served stale.
+func (e *Exporter) Collect() error {
+	start := time.Now()
+	families, err := collect(e.r)
+
+	up := 1.0
+	if err != nil {
+		up = 0
+		families = nil
+	}
+
+	families = append([]family{
+		{name: "rabbitmq_up", help: "Whether the last collection from the management api succeeded.", typ: "gauge",
+			samples: []sample{{value: up}}},
+		{name: "rabbitmq_collect_duration_seconds", help: "Duration of the last collection.", typ: "gauge",
+			samples: []sample{{value: time.Since(start).Seconds()}}},
+	}, families...)
+
+	e.mu.Lock()
+	e.families = families
+	e.mu.Unlock()
+
+	return err
+}
+
+// ServeHTTP writes the metrics of the last collection, in the OpenMetrics
+// format if the client accepts it and in the Prometheus text format
+// otherwise.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	openMetrics := strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text")
+
+	e.mu.RLock()
+	var buf bytes.Buffer
+	write(&buf, e.families, openMetrics)
+	e.mu.RUnlock()
+
+	if openMetrics {
+		w.Header().Set("Content-Type", openMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", textContentType)
+	}
+	w.Write(buf.Bytes())
+}
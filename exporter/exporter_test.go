@@ -0,0 +1,109 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/koding/rabbitapi"
+)
+
+func testBroker(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/overview":
+			w.Write([]byte(`{"object_totals":{"connections":3,"channels":5,"consumers":2,"exchanges":8,"queues":1},
+				"queue_totals":{"messages":7,"messages_ready":4,"messages_unacknowledged":3},
+				"message_stats":{"publish":100,"publish_details":{"rate":2.5},"ack":90}}`))
+		case "/api/nodes":
+			w.Write([]byte(`[{"name":"rabbit@a","running":true,"mem_used":1024,"mem_alarm":true,"uptime":5000}]`))
+		case "/api/vhosts":
+			w.Write([]byte(`[{"name":"/","messages":7}]`))
+		case "/api/queues":
+			w.Write([]byte(`[{"name":"jobs \"1\"","vhost":"/","messages":7,"consumers":2,"message_stats":{"publish":100}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func scrape(e *Exporter, accept string) (string, string) {
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Body.String(), rec.Header().Get("Content-Type")
+}
+
+func TestRabbit_Exporter(t *testing.T) {
+	ts := testBroker(t)
+	e, err := New(rabbitapi.Auth("guest", "guest", ts.URL), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Collect(); err != nil {
+		t.Fatal(err)
+	}
+
+	body, contentType := scrape(e, "")
+	if contentType != textContentType {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+
+	for _, line := range []string{
+		"rabbitmq_up 1",
+		"rabbitmq_connections 3",
+		"rabbitmq_messages_ready 4",
+		"# TYPE rabbitmq_messages_published_total counter",
+		"rabbitmq_messages_published_total 100",
+		"rabbitmq_message_publish_rate 2.5",
+		`rabbitmq_node_mem_alarm{node="rabbit@a"} 1`,
+		`rabbitmq_node_uptime_seconds{node="rabbit@a"} 5`,
+		`rabbitmq_vhost_messages{vhost="/"} 7`,
+		`rabbitmq_queue_messages{vhost="/",queue="jobs \"1\""} 7`,
+		`rabbitmq_queue_messages_published_total{vhost="/",queue="jobs \"1\""} 100`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in\n%s", line, body)
+		}
+	}
+
+	body, contentType = scrape(e, "application/openmetrics-text; version=1.0.0")
+	if contentType != openMetricsContentType {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+	if !strings.Contains(body, "# TYPE rabbitmq_messages_published counter\nrabbitmq_messages_published_total 100\n") ||
+		!strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("unexpected OpenMetrics output\n%s", body)
+	}
+}
+
+func TestRabbit_ExporterDown(t *testing.T) {
+	ts := testBroker(t)
+	ts.Close()
+
+	e, err := New(rabbitapi.Auth("guest", "guest", ts.URL), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Collect(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	body, _ := scrape(e, "")
+	if !strings.Contains(body, "rabbitmq_up 0\n") || strings.Contains(body, "rabbitmq_connections") {
+		t.Errorf("unexpected output\n%s", body)
+	}
+}
+
+func TestRabbit_ExporterInterval(t *testing.T) {
+	if _, err := New(rabbitapi.Auth("guest", "guest", "http://localhost:15672"), 0); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
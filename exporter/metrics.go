@@ -0,0 +1,202 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/koding/rabbitapi"
+)
+
+// family is a metric with all its samples. Counter names are given without
+// the _total suffix, which the formats add differently.
+type family struct {
+	name    string
+	help    string
+	typ     string // gauge or counter
+	samples []sample
+}
+
+type sample struct {
+	labels []string // name, value pairs
+	value  float64
+}
+
+func gauge(name, help string, samples ...sample) family {
+	return family{name: name, help: help, typ: "gauge", samples: samples}
+}
+
+func counter(name, help string, samples ...sample) family {
+	return family{name: name, help: help, typ: "counter", samples: samples}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// collect reads the metrics from the broker.
+func collect(r *rabbitapi.Rabbit) ([]family, error) {
+	overview, err := r.GetOverview()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := r.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	vhosts, err := r.GetVhosts()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := overview.MessageStats
+	families := []family{
+		gauge("rabbitmq_connections", "Open connections.", sample{value: float64(overview.ObjectTotals.Connections)}),
+		gauge("rabbitmq_channels", "Open channels.", sample{value: float64(overview.ObjectTotals.Channels)}),
+		gauge("rabbitmq_consumers", "Consumers.", sample{value: float64(overview.ObjectTotals.Consumers)}),
+		gauge("rabbitmq_exchanges", "Exchanges.", sample{value: float64(overview.ObjectTotals.Exchanges)}),
+		gauge("rabbitmq_queues", "Queues.", sample{value: float64(overview.ObjectTotals.Queues)}),
+		gauge("rabbitmq_messages", "Messages in all queues.", sample{value: float64(overview.QueueTotals.Messages)}),
+		gauge("rabbitmq_messages_ready", "Messages ready for delivery in all queues.", sample{value: float64(overview.QueueTotals.MessagesReady)}),
+		gauge("rabbitmq_messages_unacknowledged", "Delivered but unacknowledged messages in all queues.", sample{value: float64(overview.QueueTotals.MessagesUnacknowledged)}),
+		counter("rabbitmq_messages_published", "Messages published.", sample{value: float64(stats.Publish)}),
+		counter("rabbitmq_messages_delivered", "Messages delivered to consumers or fetched with get.", sample{value: float64(stats.DeliverGet)}),
+		counter("rabbitmq_messages_acknowledged", "Messages acknowledged by consumers.", sample{value: float64(stats.Ack)}),
+		counter("rabbitmq_messages_redelivered", "Messages redelivered.", sample{value: float64(stats.Redeliver)}),
+		counter("rabbitmq_messages_unroutable_returned", "Unroutable messages returned to publishers.", sample{value: float64(stats.ReturnUnroutable)}),
+		gauge("rabbitmq_message_publish_rate", "Messages published per second.", sample{value: stats.PublishDetails.Rate}),
+		gauge("rabbitmq_message_deliver_rate", "Messages delivered per second.", sample{value: stats.DeliverGetDetails.Rate}),
+		gauge("rabbitmq_message_ack_rate", "Messages acknowledged per second.", sample{value: stats.AckDetails.Rate}),
+	}
+
+	nodeFamilies := []struct {
+		family family
+		value  func(n rabbitapi.Node) float64
+	}{
+		{gauge("rabbitmq_node_running", "Whether the node is running."), func(n rabbitapi.Node) float64 { return boolValue(n.Running) }},
+		{gauge("rabbitmq_node_mem_used_bytes", "Memory used by the node."), func(n rabbitapi.Node) float64 { return float64(n.MemUsed) }},
+		{gauge("rabbitmq_node_mem_limit_bytes", "Memory high watermark of the node."), func(n rabbitapi.Node) float64 { return float64(n.MemLimit) }},
+		{gauge("rabbitmq_node_mem_alarm", "Whether the memory alarm of the node is raised."), func(n rabbitapi.Node) float64 { return boolValue(n.MemAlarm) }},
+		{gauge("rabbitmq_node_disk_free_bytes", "Free disk space of the node."), func(n rabbitapi.Node) float64 { return float64(n.DiskFree) }},
+		{gauge("rabbitmq_node_disk_free_limit_bytes", "Free disk space limit of the node."), func(n rabbitapi.Node) float64 { return float64(n.DiskFreeLimit) }},
+		{gauge("rabbitmq_node_disk_free_alarm", "Whether the disk alarm of the node is raised."), func(n rabbitapi.Node) float64 { return boolValue(n.DiskFreeAlarm) }},
+		{gauge("rabbitmq_node_fd_used", "File descriptors used by the node."), func(n rabbitapi.Node) float64 { return float64(n.FdUsed) }},
+		{gauge("rabbitmq_node_fd_total", "File descriptors available to the node."), func(n rabbitapi.Node) float64 { return float64(n.FdTotal) }},
+		{gauge("rabbitmq_node_sockets_used", "Sockets used by the node."), func(n rabbitapi.Node) float64 { return float64(n.SocketsUsed) }},
+		{gauge("rabbitmq_node_uptime_seconds", "Uptime of the node."), func(n rabbitapi.Node) float64 { return float64(n.Uptime) / 1000 }},
+	}
+	for _, nf := range nodeFamilies {
+		for _, n := range nodes {
+			nf.family.samples = append(nf.family.samples, sample{labels: []string{"node", n.Name}, value: nf.value(n)})
+		}
+		families = append(families, nf.family)
+	}
+
+	vhostFamilies := []struct {
+		family family
+		value  func(v rabbitapi.Vhost) float64
+	}{
+		{gauge("rabbitmq_vhost_messages", "Messages in the queues of the vhost."), func(v rabbitapi.Vhost) float64 { return float64(v.Messages) }},
+		{gauge("rabbitmq_vhost_messages_ready", "Messages ready for delivery in the queues of the vhost."), func(v rabbitapi.Vhost) float64 { return float64(v.MessagesReady) }},
+		{gauge("rabbitmq_vhost_messages_unacknowledged", "Unacknowledged messages in the queues of the vhost."), func(v rabbitapi.Vhost) float64 { return float64(v.MessagesUnacknowledged) }},
+	}
+	for _, vf := range vhostFamilies {
+		for _, v := range vhosts {
+			vf.family.samples = append(vf.family.samples, sample{labels: []string{"vhost", v.Name}, value: vf.value(v)})
+		}
+		families = append(families, vf.family)
+	}
+
+	queueFamilies := []struct {
+		family family
+		value  func(q rabbitapi.Queue) float64
+	}{
+		{gauge("rabbitmq_queue_messages", "Messages in the queue."), func(q rabbitapi.Queue) float64 { return float64(q.Messages) }},
+		{gauge("rabbitmq_queue_messages_ready", "Messages ready for delivery in the queue."), func(q rabbitapi.Queue) float64 { return float64(q.MessagesReady) }},
+		{gauge("rabbitmq_queue_messages_unacknowledged", "Unacknowledged messages in the queue."), func(q rabbitapi.Queue) float64 { return float64(q.MessagesUnacknowledged) }},
+		{gauge("rabbitmq_queue_consumers", "Consumers of the queue."), func(q rabbitapi.Queue) float64 { return float64(q.Consumers) }},
+		{counter("rabbitmq_queue_messages_published", "Messages published to the queue."), func(q rabbitapi.Queue) float64 { return float64(q.MessageStats.Publish) }},
+		{counter("rabbitmq_queue_messages_delivered", "Messages delivered from the queue."), func(q rabbitapi.Queue) float64 { return float64(q.MessageStats.DeliverGet) }},
+		{gauge("rabbitmq_queue_message_publish_rate", "Messages published to the queue per second."), func(q rabbitapi.Queue) float64 { return q.MessageStats.PublishDetails.Rate }},
+		{gauge("rabbitmq_queue_message_deliver_rate", "Messages delivered from the queue per second."), func(q rabbitapi.Queue) float64 { return q.MessageStats.DeliverGetDetails.Rate }},
+	}
+	// queues are streamed, so the raw JSON list of all queues is never
+	// buffered; their samples are still kept until the next interval
+	for q, err := range r.StreamQueues() {
+		if err != nil {
+			return nil, err
+		}
+
+		labels := []string{"vhost", q.Vhost, "queue", q.Name}
+		for i := range queueFamilies {
+			qf := &queueFamilies[i]
+			qf.family.samples = append(qf.family.samples, sample{labels: labels, value: qf.value(q)})
+		}
+	}
+	for _, qf := range queueFamilies {
+		families = append(families, qf.family)
+	}
+
+	return families, nil
+}
+
+// write writes the families in the Prometheus text format, or in the
+// OpenMetrics format if openMetrics is set.
+func write(w io.Writer, families []family, openMetrics bool) {
+	for _, f := range families {
+		if len(f.samples) == 0 {
+			continue
+		}
+
+		name := f.name
+		if f.typ == "counter" && !openMetrics {
+			name += "_total"
+		}
+		fmt.Fprintf(w, "# HELP %s %s\n", name, escape(f.help, openMetrics))
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, f.typ)
+
+		sampleName := f.name
+		if f.typ == "counter" {
+			sampleName += "_total"
+		}
+
+		for _, s := range f.samples {
+			fmt.Fprintf(w, "%s%s %s\n", sampleName, labels(s.labels), strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+
+	if openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
+
+func labels(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], escape(pairs[i+1], true)))
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escape escapes backslashes and newlines, and double quotes if quotes is
+// set (label values, and help texts in OpenMetrics).
+func escape(s string, quotes bool) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	if quotes {
+		s = strings.Replace(s, `"`, `\"`, -1)
+	}
+
+	return s
+}
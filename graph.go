@@ -0,0 +1,248 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GetVhostTopology returns the exchanges, queues and bindings of a vhost, as
+// needed by Topology.DOT and Topology.Mermaid. Bindings are collected with
+// GetExchangeSource for every exchange except the default exchange, whose
+// implicit binding to each queue is left out.
+func (r *Rabbit) GetVhostTopology(vhost string) (Topology, error) {
+	exchanges, err := r.GetVhostExchanges(vhost)
+	if err != nil {
+		return Topology{}, err
+	}
+
+	queues, err := r.GetVhostQueues(vhost)
+	if err != nil {
+		return Topology{}, err
+	}
+
+	t := Topology{
+		Vhosts:    []Vhost{{Name: vhost}},
+		Exchanges: exchanges,
+		Queues:    queues,
+		Bindings:  make([]Binding, 0),
+	}
+
+	for _, e := range exchanges {
+		if e.Name == "" {
+			continue
+		}
+
+		bindings, err := r.GetExchangeSource(vhost, e.Name)
+		if err != nil {
+			return Topology{}, err
+		}
+		t.Bindings = append(t.Bindings, bindings...)
+	}
+
+	return t, nil
+}
+
+// graphStyles are the fill colors of exchanges by type, other types are gray.
+var graphStyles = map[string]string{
+	string(ExchangeDirect):  "#cfe2f3",
+	string(ExchangeFanout):  "#d9ead3",
+	string(ExchangeTopic):   "#fce5cd",
+	string(ExchangeHeaders): "#d9d2e9",
+}
+
+const graphDefaultStyle = "#eeeeee"
+
+const graphQueueStyle = "#fff2cc"
+
+// graph is the part of a topology drawn for a vhost.
+type graph struct {
+	exchanges []Exchange
+	queues    []Queue
+	bindings  []Binding
+	kinds     map[string]string // exchange name to type
+}
+
+// vhostGraph selects the objects of vhost from the topology. The default
+// exchange and the unused amq.* exchanges are left out to keep the picture
+// readable.
+func (t Topology) vhostGraph(vhost string) graph {
+	g := graph{kinds: make(map[string]string)}
+
+	used := make(map[string]bool)
+	for _, b := range t.Bindings {
+		if b.Vhost != vhost || b.Source == "" {
+			continue
+		}
+		g.bindings = append(g.bindings, b)
+		used[b.Source] = true
+		if b.DestinationType == DestinationExchange {
+			used[b.Destination] = true
+		}
+	}
+
+	for _, e := range t.Exchanges {
+		if e.Vhost != vhost || e.Name == "" {
+			continue
+		}
+		if isDefaultExchange(e.Name) && !used[e.Name] {
+			continue
+		}
+		g.exchanges = append(g.exchanges, e)
+		g.kinds[e.Name] = e.Type
+	}
+
+	for _, q := range t.Queues {
+		if q.Vhost == vhost {
+			g.queues = append(g.queues, q)
+		}
+	}
+
+	sort.Slice(g.exchanges, func(i, j int) bool { return g.exchanges[i].Name < g.exchanges[j].Name })
+	sort.Slice(g.queues, func(i, j int) bool { return g.queues[i].Name < g.queues[j].Name })
+	sort.SliceStable(g.bindings, func(i, j int) bool { return bindingKey(g.bindings[i]) < bindingKey(g.bindings[j]) })
+
+	return g
+}
+
+// label returns the edge label of a binding: the routing key, or the matched
+// headers for headers exchanges.
+func (g graph) label(b Binding) string {
+	if g.kinds[b.Source] != string(ExchangeHeaders) {
+		return b.RoutingKey
+	}
+
+	keys := make([]string, 0, len(b.Arguments))
+	for k := range b.Arguments {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, b.Arguments[k])
+	}
+
+	return strings.Join(pairs, ", ")
+}
+
+// DOT returns a Graphviz digraph of the routing topology of vhost. Exchanges
+// are boxes filled by exchange type, queues are ellipses and bindings are
+// edges labeled with their routing key (or the matched headers for headers
+// exchanges). Render it with e.g. "dot -Tsvg".
+func (t Topology) DOT(vhost string) string {
+	g := t.vhostGraph(vhost)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote("vhost "+vhost))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [fontname=\"Helvetica\" style=filled];\n")
+	b.WriteString("\tedge [fontname=\"Helvetica\" fontsize=10];\n")
+
+	for _, e := range g.exchanges {
+		fill, ok := graphStyles[e.Type]
+		if !ok {
+			fill = graphDefaultStyle
+		}
+		fmt.Fprintf(&b, "\t%s [label=%s shape=box fillcolor=%s];\n",
+			dotQuote("exchange:"+e.Name), dotQuote(e.Name+"\n("+e.Type+")"), dotQuote(fill))
+	}
+
+	for _, q := range g.queues {
+		fmt.Fprintf(&b, "\t%s [label=%s shape=ellipse fillcolor=%s];\n",
+			dotQuote("queue:"+q.Name), dotQuote(q.Name), dotQuote(graphQueueStyle))
+	}
+
+	for _, binding := range g.bindings {
+		fmt.Fprintf(&b, "\t%s -> %s", dotQuote("exchange:"+binding.Source), dotQuote(string(binding.DestinationType)+":"+binding.Destination))
+		if label := g.label(binding); label != "" {
+			fmt.Fprintf(&b, " [label=%s]", dotQuote(label))
+		}
+		b.WriteString(";\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote returns s as a DOT quoted string.
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return `"` + s + `"`
+}
+
+// Mermaid returns a Mermaid flowchart of the routing topology of vhost, in
+// the same layout as DOT. It can be embedded in Markdown in a "mermaid" code
+// block.
+func (t Topology) Mermaid(vhost string) string {
+	g := t.vhostGraph(vhost)
+
+	// mermaid node ids can't contain arbitrary characters, so nodes are
+	// numbered and names only appear in labels
+	ids := make(map[string]string)
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	classes := make(map[string]bool)
+	for i, e := range g.exchanges {
+		id := fmt.Sprintf("e%d", i)
+		ids["exchange:"+e.Name] = id
+
+		class := "other"
+		if _, ok := graphStyles[e.Type]; ok {
+			class = e.Type
+		}
+		classes[class] = true
+		fmt.Fprintf(&b, "\t%s[%s]:::%s\n", id, mermaidQuote(e.Name+"<br/>("+e.Type+")"), class)
+	}
+
+	for i, q := range g.queues {
+		id := fmt.Sprintf("q%d", i)
+		ids["queue:"+q.Name] = id
+		fmt.Fprintf(&b, "\t%s([%s]):::queue\n", id, mermaidQuote(q.Name))
+	}
+
+	for _, binding := range g.bindings {
+		from, ok := ids["exchange:"+binding.Source]
+		if !ok {
+			continue
+		}
+		to, ok := ids[string(binding.DestinationType)+":"+binding.Destination]
+		if !ok {
+			continue
+		}
+
+		if label := g.label(binding); label != "" {
+			fmt.Fprintf(&b, "\t%s -- %s --> %s\n", from, mermaidQuote(label), to)
+		} else {
+			fmt.Fprintf(&b, "\t%s --> %s\n", from, to)
+		}
+	}
+
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+	sort.Strings(names)
+
+	for _, class := range names {
+		fill, ok := graphStyles[class]
+		if !ok {
+			fill = graphDefaultStyle
+		}
+		fmt.Fprintf(&b, "\tclassDef %s fill:%s\n", class, fill)
+	}
+	if len(g.queues) != 0 {
+		fmt.Fprintf(&b, "\tclassDef queue fill:%s\n", graphQueueStyle)
+	}
+
+	return b.String()
+}
+
+// mermaidQuote returns s as a Mermaid quoted label.
+func mermaidQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, "#quot;", -1) + `"`
+}
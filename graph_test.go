@@ -0,0 +1,118 @@
+package rabbitapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// definitions as exported by the management api
+const graphDefinitions = `{
+  "rabbit_version": "3.12.0",
+  "vhosts": [{"name": "tenant"}],
+  "exchanges": [
+    {"vhost": "tenant", "name": "events", "type": "topic", "durable": true},
+    {"vhost": "tenant", "name": "audit", "type": "fanout", "durable": true},
+    {"vhost": "tenant", "name": "reports", "type": "headers", "durable": true},
+    {"vhost": "tenant", "name": "amq.direct", "type": "direct", "durable": true}
+  ],
+  "queues": [
+    {"vhost": "tenant", "name": "jobs", "durable": true},
+    {"vhost": "tenant", "name": "audit-log", "durable": true},
+    {"vhost": "tenant", "name": "pdf", "durable": true}
+  ],
+  "bindings": [
+    {"vhost": "tenant", "source": "events", "destination": "jobs", "destination_type": "queue", "routing_key": "jobs.#", "arguments": {}},
+    {"vhost": "tenant", "source": "events", "destination": "audit", "destination_type": "exchange", "routing_key": "#", "arguments": {}},
+    {"vhost": "tenant", "source": "audit", "destination": "audit-log", "destination_type": "queue", "routing_key": "", "arguments": {}},
+    {"vhost": "tenant", "source": "reports", "destination": "pdf", "destination_type": "queue", "routing_key": "", "arguments": {"x-match": "all", "format": "pdf"}}
+  ]
+}`
+
+func TestRabbit_TopologyDOT(t *testing.T) {
+	topology, err := LoadTopology([]byte(graphDefinitions))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph "vhost tenant" {
+	rankdir=LR;
+	node [fontname="Helvetica" style=filled];
+	edge [fontname="Helvetica" fontsize=10];
+	"exchange:audit" [label="audit\n(fanout)" shape=box fillcolor="#d9ead3"];
+	"exchange:events" [label="events\n(topic)" shape=box fillcolor="#fce5cd"];
+	"exchange:reports" [label="reports\n(headers)" shape=box fillcolor="#d9d2e9"];
+	"queue:audit-log" [label="audit-log" shape=ellipse fillcolor="#fff2cc"];
+	"queue:jobs" [label="jobs" shape=ellipse fillcolor="#fff2cc"];
+	"queue:pdf" [label="pdf" shape=ellipse fillcolor="#fff2cc"];
+	"exchange:audit" -> "queue:audit-log";
+	"exchange:events" -> "exchange:audit" [label="#"];
+	"exchange:events" -> "queue:jobs" [label="jobs.#"];
+	"exchange:reports" -> "queue:pdf" [label="format=pdf, x-match=all"];
+}
+`
+
+	if got := topology.DOT("tenant"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRabbit_TopologyMermaid(t *testing.T) {
+	topology, err := LoadTopology([]byte(graphDefinitions))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `flowchart LR
+	e0["audit<br/>(fanout)"]:::fanout
+	e1["events<br/>(topic)"]:::topic
+	e2["reports<br/>(headers)"]:::headers
+	q0(["audit-log"]):::queue
+	q1(["jobs"]):::queue
+	q2(["pdf"]):::queue
+	e0 --> q0
+	e1 -- "#" --> e0
+	e1 -- "jobs.#" --> q1
+	e2 -- "format=pdf, x-match=all" --> q2
+	classDef fanout fill:#d9ead3
+	classDef headers fill:#d9d2e9
+	classDef topic fill:#fce5cd
+	classDef queue fill:#fff2cc
+`
+
+	if got := topology.Mermaid("tenant"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRabbit_GetVhostTopology(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.EscapedPath() {
+		case "/api/exchanges/%2f":
+			w.Write([]byte(`[{"name":"","vhost":"/","type":"direct"},{"name":"events","vhost":"/","type":"topic"}]`))
+		case "/api/queues/%2f":
+			w.Write([]byte(`[{"name":"jobs","vhost":"/"}]`))
+		case "/api/exchanges/%2f/events/bindings/source":
+			w.Write([]byte(`[{"source":"events","vhost":"/","destination":"jobs","destination_type":"queue","routing_key":"jobs.#"}]`))
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	topology, err := r.GetVhostTopology("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(topology.Exchanges) != 2 || len(topology.Queues) != 1 || len(topology.Bindings) != 1 {
+		t.Fatalf("unexpected topology %+v", topology)
+	}
+
+	if dot := topology.DOT("/"); !strings.Contains(dot, `"exchange:events" -> "queue:jobs" [label="jobs.#"];`) {
+		t.Errorf("missing binding in\n%s", dot)
+	}
+}
@@ -0,0 +1,124 @@
+package rabbitapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestInfo describes a request sent to the management api.
+type RequestInfo struct {
+	Method string
+
+	// Endpoint is the endpoint with its parameters replaced by names, e.g.
+	// /api/exchanges/{vhost}/{name}, so that it can be used as a metric
+	// label. Path is the actual endpoint and Query its query string
+	// (without "?"), e.g. page=2&name=jobs.
+	Endpoint string
+	Path     string
+	Query    string
+
+	// StatusCode is 0 if no response was received.
+	StatusCode int
+
+	// Duration ends when the response headers are received. For the
+	// Stream* iterators, which read the body while decoding it, it
+	// doesn't include the transfer of the body.
+	Start    time.Time
+	Duration time.Duration
+
+	// Err is the error returned for the request, if any.
+	Err error
+}
+
+// Hook is called after every request sent to the management api, including
+// every attempt of retried requests. Hooks are called synchronously, so they
+// should be fast.
+type Hook interface {
+	RequestDone(info RequestInfo)
+}
+
+// HookFunc is a function used as a Hook.
+type HookFunc func(info RequestInfo)
+
+func (f HookFunc) RequestDone(info RequestInfo) {
+	f(info)
+}
+
+func (r *Rabbit) callHooks(method, endpoint string, start time.Time, resp *http.Response, err error) {
+	path, query, _ := strings.Cut(endpoint, "?")
+	info := RequestInfo{
+		Method:   method,
+		Endpoint: EndpointTemplate(endpoint),
+		Path:     path,
+		Query:    query,
+		Start:    start,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+
+	var apiErr *APIError
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	} else if errors.As(err, &apiErr) {
+		info.StatusCode = apiErr.StatusCode
+	}
+
+	for _, hook := range r.Hooks {
+		hook.RequestDone(info)
+	}
+}
+
+// endpointParams are the names of the path segments following the resource
+// of an endpoint. Empty names keep the segment as it is.
+var endpointParams = map[string][]string{
+	"aliveness-test":    {"vhost"},
+	"bindings":          {"vhost", "", "source", "", "destination", "props"},
+	"connections":       {"name", ""},
+	"definitions":       {"vhost"},
+	"exchanges":         {"vhost", "name", "", ""},
+	"nodes":             {"name"},
+	"permissions":       {"vhost", "user"},
+	"policies":          {"vhost", "name"},
+	"queues":            {"vhost", "name", ""},
+	"topic-permissions": {"vhost", "user"},
+	"users":             {"name", ""},
+	"vhost-limits":      {"vhost", "name"},
+	"vhosts":            {"name", ""},
+}
+
+// fixedEndpoints are endpoints whose segments look like parameters but are
+// part of the endpoint.
+var fixedEndpoints = map[string]bool{
+	"/api/users/without-permissions": true,
+	"/api/users/bulk-delete":         true,
+}
+
+// EndpointTemplate returns the endpoint with its parameters (vhost, names,
+// ...) replaced by their names in braces and the query string removed, e.g.
+// /api/exchanges/{vhost}/{name} for /api/exchanges/%2f/events.
+func EndpointTemplate(endpoint string) string {
+	endpoint = strings.SplitN(endpoint, "?", 2)[0]
+	if fixedEndpoints[endpoint] {
+		return endpoint
+	}
+
+	segments := strings.Split(endpoint, "/")
+	if len(segments) < 4 || segments[1] != "api" {
+		return endpoint
+	}
+
+	params := endpointParams[segments[2]]
+	for i := 3; i < len(segments); i++ {
+		name := "param"
+		if i-3 < len(params) {
+			name = params[i-3]
+		}
+		if name != "" {
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
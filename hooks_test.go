@@ -0,0 +1,69 @@
+package rabbitapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRabbit_EndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/api/overview?lengths_age=60":                "/api/overview",
+		"/api/exchanges/%2f/events":                   "/api/exchanges/{vhost}/{name}",
+		"/api/exchanges/%2f/events/bindings/source":   "/api/exchanges/{vhost}/{name}/bindings/source",
+		"/api/bindings/tenant/e/events/q/jobs/~":      "/api/bindings/{vhost}/e/{source}/q/{destination}/{props}",
+		"/api/users/app/permissions":                  "/api/users/{name}/permissions",
+		"/api/connections/10.0.0.1%3A5000%20-%3E%20x": "/api/connections/{name}",
+		"/api/unknown/a/b":                            "/api/unknown/{param}/{param}",
+		"/api/users/without-permissions":              "/api/users/without-permissions",
+		"/api/users/guest":                            "/api/users/{name}",
+	}
+
+	for endpoint, want := range tests {
+		if got := EndpointTemplate(endpoint); got != want {
+			t.Errorf("%s: got %s, want %s", endpoint, got, want)
+		}
+	}
+}
+
+func TestRabbit_Hooks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/api/vhosts/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"name":"/"}`))
+	}))
+	defer ts.Close()
+
+	infos := make([]RequestInfo, 0)
+	r := Auth("guest", "guest", ts.URL)
+	r.Hooks = []Hook{HookFunc(func(info RequestInfo) { infos = append(infos, info) })}
+
+	if _, err := r.GetVhost("/"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetVhost("missing"); !IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, err := r.GetVhost("/", StatsOptions{LengthsAge: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 3 {
+		t.Fatalf("expected 3 calls, got %d", len(infos))
+	}
+
+	if i := infos[0]; i.Method != "GET" || i.Endpoint != "/api/vhosts/{name}" || i.Path != "/api/vhosts/%2f" || i.StatusCode != 200 || i.Err != nil {
+		t.Errorf("unexpected info %+v", i)
+	}
+
+	if i := infos[1]; i.StatusCode != 404 || i.Err == nil {
+		t.Errorf("unexpected info %+v", i)
+	}
+
+	if i := infos[2]; i.Path != "/api/vhosts/%2f" || i.Query != "lengths_age=60" {
+		t.Errorf("unexpected info %+v", i)
+	}
+}
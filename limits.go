@@ -0,0 +1,78 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+)
+
+// Vhost limits, see https://www.rabbitmq.com/vhosts.html#limits
+const (
+	LimitMaxConnections = "max-connections"
+	LimitMaxQueues      = "max-queues"
+)
+
+type vhostLimits struct {
+	Vhost string         `json:"vhost"`
+	Value map[string]int `json:"value"`
+}
+
+// GetVhostLimits returns the limits of a given vhost, keyed by limit name
+// (e.g. LimitMaxConnections). Vhosts without limits return an empty map.
+func (r *Rabbit) GetVhostLimits(vhost string) (map[string]int, error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	body, err := r.doRequest("GET", "/api/vhost-limits/"+vhost, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]vhostLimits, 0)
+	err = json.Unmarshal(body, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[string]int)
+	for _, l := range list {
+		for name, value := range l.Value {
+			limits[name] = value
+		}
+	}
+
+	return limits, nil
+}
+
+// SetVhostLimit sets a limit of a given vhost. A negative value means no
+// limit, zero disallows connections or queues altogether.
+func (r *Rabbit) SetVhostLimit(vhost, name string, value int) error {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	data, err := json.Marshal(map[string]int{"value": value})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.doRequest("PUT", "/api/vhost-limits/"+vhost+"/"+name, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteVhostLimit removes a limit of a given vhost.
+func (r *Rabbit) DeleteVhostLimit(vhost, name string) error {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	_, err := r.doRequest("DELETE", "/api/vhost-limits/"+vhost+"/"+name, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
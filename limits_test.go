@@ -0,0 +1,26 @@
+package rabbitapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRabbit_GetVhostLimits(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.EscapedPath() != "/api/vhost-limits/%2f" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		w.Write([]byte(`[{"vhost":"/","value":{"max-connections":10,"max-queues":-1}}]`))
+	}))
+	defer ts.Close()
+
+	limits, err := Auth("guest", "guest", ts.URL).GetVhostLimits("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limits[LimitMaxConnections] != 10 || limits[LimitMaxQueues] != -1 {
+		t.Errorf("unexpected limits %v", limits)
+	}
+}
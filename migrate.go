@@ -0,0 +1,376 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type MigrationMode string
+
+const (
+	// MigrateSkipExisting leaves objects which already exist on the
+	// destination alone, even if they differ from the source.
+	MigrateSkipExisting MigrationMode = "skip-existing"
+
+	// MigrateOverwrite replaces differing objects on the destination with
+	// the source ones. Exchanges and queues can't be changed in place, they
+	// are deleted and declared again, which drops the messages of a queue and
+	// the bindings that are not part of the source.
+	MigrateOverwrite MigrationMode = "overwrite"
+)
+
+// MigrateOptions configure Migrate.
+type MigrateOptions struct {
+	// Vhosts are the source vhosts to migrate. All vhosts are migrated if
+	// it's empty. Only the users with permissions on these vhosts are
+	// migrated then.
+	Vhosts []string
+
+	// RenameVhosts maps source vhost names to destination vhost names.
+	// Vhosts which are not in the map keep their name.
+	RenameVhosts map[string]string
+
+	// Mode defaults to MigrateSkipExisting.
+	Mode MigrationMode
+
+	// DryRun only reports the steps a migration would take, nothing is
+	// changed on the destination.
+	DryRun bool
+}
+
+// MigrationStep is the outcome of migrating a single object.
+type MigrationStep struct {
+	Change
+
+	// SkipReason is set if the step was not needed ("unchanged"), not
+	// allowed ("exists" with MigrateSkipExisting) or not supported by the
+	// destination broker.
+	SkipReason string
+
+	// Err is the error applying the step.
+	Err error
+}
+
+// MigrationReport describes every step of a migration, in the order they
+// were applied.
+type MigrationReport struct {
+	DryRun bool
+	Steps  []MigrationStep
+}
+
+func (r MigrationReport) String() string {
+	title := "migration:"
+	if r.DryRun {
+		title = "migration (dry run):"
+	}
+
+	lines := make([]string, 0, len(r.Steps)+1)
+	lines = append(lines, title)
+	for _, step := range r.Steps {
+		switch {
+		case step.SkipReason != "":
+			lines = append(lines, fmt.Sprintf("  %s: skipped (%s)", step.Change, step.SkipReason))
+		case step.Err != nil:
+			lines = append(lines, fmt.Sprintf("  %s: %s", step.Change, step.Err))
+		case r.DryRun:
+			lines = append(lines, fmt.Sprintf("  %s: planned", step.Change))
+		default:
+			lines = append(lines, fmt.Sprintf("  %s: ok", step.Change))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Failed returns the steps which failed.
+func (r MigrationReport) Failed() []MigrationStep {
+	failed := make([]MigrationStep, 0)
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			failed = append(failed, step)
+		}
+	}
+
+	return failed
+}
+
+// Migrate copies vhosts, users (with their password hashes), permissions,
+// exchanges, queues, bindings and policies from the src broker to the dst
+// broker, e.g. to move to a new cluster. Both clients need the administrator
+// tag. Objects are created in dependency order; the default exchanges and
+// the user dst authenticates as are never touched. Objects identical on both
+// brokers are skipped, so a migration can be run again after fixing a
+// failure. Policies with classic queue mirroring keys (ha-mode, ha-params,
+// ha-sync-mode) are skipped for a RabbitMQ 4.x destination, which rejects
+// them.
+//
+// A failed step doesn't stop the migration, as most of the remaining steps
+// are independent of it. The returned error summarizes the failed steps, the
+// report has the outcome of every step.
+func Migrate(src, dst *Rabbit, opts MigrateOptions) (MigrationReport, error) {
+	report := MigrationReport{DryRun: opts.DryRun}
+
+	source, err := src.GetDefinitions()
+	if err != nil {
+		return report, fmt.Errorf("reading source definitions: %s", err)
+	}
+
+	current, err := dst.definitions()
+	if err != nil {
+		return report, fmt.Errorf("reading destination definitions: %s", err)
+	}
+
+	report.Steps = planMigration(migrationSource(source, opts), current.topology(), current.Exported, opts.Mode, dst.Username)
+	if opts.DryRun {
+		return report, nil
+	}
+
+	failed := 0
+	for i, step := range report.Steps {
+		if step.SkipReason != "" {
+			continue
+		}
+
+		if err := step.apply(dst); err != nil {
+			report.Steps[i].Err = err
+			failed++
+		}
+	}
+
+	if failed != 0 {
+		return report, fmt.Errorf("%d of %d migration steps failed", failed, len(report.Steps))
+	}
+
+	return report, nil
+}
+
+// migrationSource returns the objects of d selected by opts, with the vhosts
+// renamed.
+func migrationSource(d Definitions, opts MigrateOptions) Definitions {
+	selected := func(vhost string) bool {
+		if len(opts.Vhosts) == 0 {
+			return true
+		}
+		for _, v := range opts.Vhosts {
+			if v == vhost {
+				return true
+			}
+		}
+		return false
+	}
+
+	rename := func(vhost string) string {
+		if name, ok := opts.RenameVhosts[vhost]; ok {
+			return name
+		}
+		return vhost
+	}
+
+	out := Definitions{}
+	for _, v := range d.Vhosts {
+		if selected(v.Name) {
+			out.Vhosts = append(out.Vhosts, Vhost{Name: rename(v.Name), Tracing: v.Tracing})
+		}
+	}
+
+	users := make(map[string]bool)
+	for _, p := range d.Permissions {
+		if selected(p.Vhost) {
+			users[p.User] = true
+			p.Vhost = rename(p.Vhost)
+			out.Permissions = append(out.Permissions, p)
+		}
+	}
+
+	for _, u := range d.Users {
+		if len(opts.Vhosts) != 0 && !users[u.Name] {
+			continue
+		}
+
+		// brokers before 3.6 don't export the algorithm, they only
+		// supported md5
+		if u.PasswordHash != "" && u.HashingAlgorithm == "" {
+			u.HashingAlgorithm = HashingMD5
+		}
+		out.Users = append(out.Users, u)
+	}
+
+	for _, e := range d.Exchanges {
+		if selected(e.Vhost) && !isDefaultExchange(e.Name) {
+			e.Vhost = rename(e.Vhost)
+			out.Exchanges = append(out.Exchanges, e)
+		}
+	}
+
+	for _, q := range d.Queues {
+		if selected(q.Vhost) && !strings.HasPrefix(q.Name, "amq.") {
+			q.Vhost = rename(q.Vhost)
+			out.Queues = append(out.Queues, q)
+		}
+	}
+
+	for _, b := range d.Bindings {
+		if selected(b.Vhost) && b.Source != "" {
+			if b.DestinationType == "" {
+				b.DestinationType = DestinationQueue
+			}
+			b.Vhost = rename(b.Vhost)
+			out.Bindings = append(out.Bindings, b)
+		}
+	}
+
+	for _, p := range d.Policies {
+		if selected(p.Vhost) {
+			p.Vhost = rename(p.Vhost)
+			out.Policies = append(out.Policies, p)
+		}
+	}
+
+	return out
+}
+
+// planMigration returns the steps to copy source to a broker with the
+// current definitions and version. self is the user of the destination
+// client.
+func planMigration(source, current Definitions, version string, mode MigrationMode, self string) []MigrationStep {
+	steps := make([]MigrationStep, 0)
+
+	// add adds the change of an object. exists and equal tell whether it
+	// exists on the destination and whether it's identical there. It
+	// reports whether the object is replaced.
+	add := func(change Change, exists, equal bool) bool {
+		step := MigrationStep{Change: change}
+		switch {
+		case !exists:
+		case equal:
+			step.SkipReason = "unchanged"
+		case mode != MigrateOverwrite:
+			step.SkipReason = "exists"
+		default:
+			step.Action = ChangeUpdate
+		}
+
+		steps = append(steps, step)
+		return step.Action == ChangeUpdate
+	}
+
+	vhosts := make(map[string]bool)
+	for _, v := range current.Vhosts {
+		vhosts[v.Name] = true
+	}
+	for _, v := range source.Vhosts {
+		add(createVhostChange(v), vhosts[v.Name], true)
+	}
+
+	users := make(map[string]User)
+	for _, u := range current.Users {
+		users[u.Name] = u
+	}
+	for _, u := range source.Users {
+		if u.Name == self {
+			steps = append(steps, MigrationStep{Change: createUserChange(u), SkipReason: "credentials of the destination"})
+			continue
+		}
+
+		cur, ok := users[u.Name]
+		if cur.PasswordHash != "" && cur.HashingAlgorithm == "" {
+			cur.HashingAlgorithm = HashingMD5 // as for the source, see migrationSource
+		}
+		equal := cur.PasswordHash == u.PasswordHash && cur.HashingAlgorithm == u.HashingAlgorithm && cur.Tags.equal(u.Tags)
+		add(createUserChange(u), ok, equal)
+	}
+
+	permissions := make(map[string]Permission)
+	for _, p := range current.Permissions {
+		permissions[p.Vhost+"\x00"+p.User] = p
+	}
+	for _, p := range source.Permissions {
+		cur, ok := permissions[p.Vhost+"\x00"+p.User]
+		equal := cur.Configure == p.Configure && cur.Write == p.Write && cur.Read == p.Read
+		add(setPermissionChange(ChangeCreate, p), ok, equal)
+	}
+
+	// replaced exchanges and queues lose their bindings
+	replaced := make(map[string]bool)
+
+	exchanges := make(map[string]Exchange)
+	for _, e := range current.Exchanges {
+		exchanges[e.Vhost+"\x00"+e.Name] = e
+	}
+	for _, e := range source.Exchanges {
+		key := e.Vhost + "\x00" + e.Name
+		cur, ok := exchanges[key]
+		if add(createExchangeChange(ChangeCreate, e), ok, equalExchange(cur, e)) {
+			steps[len(steps)-1].Change = createExchangeChange(ChangeRecreate, e)
+			replaced["e\x00"+key] = true
+		}
+	}
+
+	queues := make(map[string]Queue)
+	for _, q := range current.Queues {
+		queues[q.Vhost+"\x00"+q.Name] = q
+	}
+	for _, q := range source.Queues {
+		key := q.Vhost + "\x00" + q.Name
+		cur, ok := queues[key]
+		if add(createQueueChange(ChangeCreate, q), ok, equalQueue(cur, q)) {
+			steps[len(steps)-1].Change = createQueueChange(ChangeRecreate, q)
+			replaced["q\x00"+key] = true
+		}
+	}
+
+	bindings := make(map[string]bool)
+	for _, b := range current.Bindings {
+		if replaced["e\x00"+b.Vhost+"\x00"+b.Source] ||
+			replaced[bindingDestination(b.DestinationType)+"\x00"+b.Vhost+"\x00"+b.Destination] {
+			continue
+		}
+		bindings[bindingKey(b)] = true
+	}
+	for _, b := range source.Bindings {
+		add(createBindingChange(b), bindings[bindingKey(b)], true)
+	}
+
+	policies := make(map[string]Policy)
+	for _, p := range current.Policies {
+		policies[p.Vhost+"\x00"+p.Name] = p
+	}
+	for _, p := range source.Policies {
+		if keys := mirroringKeys(p); len(keys) != 0 && majorVersion(version) >= 4 {
+			reason := fmt.Sprintf("classic queue mirroring (%s) is not supported by RabbitMQ %s", strings.Join(keys, ", "), version)
+			steps = append(steps, MigrationStep{Change: createPolicyChange(ChangeCreate, p), SkipReason: reason})
+			continue
+		}
+
+		cur, ok := policies[p.Vhost+"\x00"+p.Name]
+		add(createPolicyChange(ChangeCreate, p), ok, equalPolicy(cur, p))
+	}
+
+	return steps
+}
+
+// mirroringKeys returns the classic queue mirroring keys of the policy
+// definition, which RabbitMQ 4.0 removed.
+func mirroringKeys(p Policy) []string {
+	keys := make([]string, 0)
+	for _, key := range []string{"ha-mode", "ha-params", "ha-sync-mode"} {
+		if _, ok := p.Definition[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// majorVersion returns the major version of a broker version like "4.0.5",
+// or 0 if it's unknown.
+func majorVersion(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
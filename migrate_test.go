@@ -0,0 +1,205 @@
+package rabbitapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// definitionsServer serves definitions and records the changing requests.
+func definitionsServer(t *testing.T, definitions string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	requests := make([]string, 0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "GET" && req.URL.Path == "/api/definitions" {
+			w.Write([]byte(definitions))
+			return
+		}
+
+		mu.Lock()
+		requests = append(requests, req.Method+" "+req.URL.EscapedPath())
+		mu.Unlock()
+
+		if req.Method == "POST" {
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, requests...)
+	}
+}
+
+const migrateSource = `{
+  "rabbit_version": "3.5.7",
+  "users": [
+    {"name": "admin", "password_hash": "a", "tags": "administrator"},
+    {"name": "app", "password_hash": "b", "tags": "monitoring"},
+    {"name": "other", "password_hash": "c", "tags": ""}
+  ],
+  "vhosts": [{"name": "tenant"}, {"name": "skipped"}],
+  "permissions": [
+    {"user": "app", "vhost": "tenant", "configure": ".*", "write": ".*", "read": ".*"},
+    {"user": "other", "vhost": "skipped", "configure": ".*", "write": ".*", "read": ".*"}
+  ],
+  "exchanges": [
+    {"name": "events", "vhost": "tenant", "type": "topic", "durable": true, "arguments": {}},
+    {"name": "audit", "vhost": "tenant", "type": "fanout", "durable": true, "arguments": {}},
+    {"name": "other", "vhost": "skipped", "type": "fanout", "durable": true, "arguments": {}}
+  ],
+  "queues": [
+    {"name": "jobs", "vhost": "tenant", "durable": true, "arguments": {}}
+  ],
+  "bindings": [
+    {"source": "events", "vhost": "tenant", "destination": "jobs", "destination_type": "queue", "routing_key": "jobs.#", "arguments": {}}
+  ],
+  "policies": [
+    {"vhost": "tenant", "name": "ttl", "pattern": "^jobs$", "apply-to": "queues", "definition": {"message-ttl": 60000}, "priority": 0}
+  ]
+}`
+
+// the destination already has the vhost, an identical queue and a
+// different audit exchange
+const migrateDestination = `{
+  "rabbit_version": "4.0.5",
+  "users": [{"name": "admin", "password_hash": "z", "hashing_algorithm": "rabbit_password_hashing_sha256", "tags": ["administrator"]}],
+  "vhosts": [{"name": "/"}, {"name": "customer"}],
+  "permissions": [],
+  "exchanges": [
+    {"name": "audit", "vhost": "customer", "type": "fanout", "durable": false, "arguments": {}}
+  ],
+  "queues": [
+    {"name": "jobs", "vhost": "customer", "durable": true, "arguments": {}}
+  ],
+  "bindings": [],
+  "policies": []
+}`
+
+func TestRabbit_MigrateDryRun(t *testing.T) {
+	src, _ := definitionsServer(t, migrateSource)
+	dst, requests := definitionsServer(t, migrateDestination)
+
+	report, err := Migrate(Auth("admin", "a", src.URL), Auth("admin", "z", dst.URL), MigrateOptions{
+		Vhosts:       []string{"tenant"},
+		RenameVhosts: map[string]string{"tenant": "customer"},
+		DryRun:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `migration (dry run):
+  create vhost customer: skipped (unchanged)
+  create user app: planned
+  create permission app in vhost customer: planned
+  create exchange events in vhost customer: planned
+  create exchange audit in vhost customer: skipped (exists)
+  create queue jobs in vhost customer: skipped (unchanged)
+  create binding events -> queue jobs ("jobs.#") in vhost customer: planned
+  create policy ttl in vhost customer: planned`
+
+	if got := report.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if len(requests()) != 0 {
+		t.Errorf("dry run changed the destination: %v", requests())
+	}
+}
+
+func TestRabbit_MigrateOverwrite(t *testing.T) {
+	src, _ := definitionsServer(t, migrateSource)
+	dst, requests := definitionsServer(t, migrateDestination)
+
+	report, err := Migrate(Auth("admin", "a", src.URL), Auth("admin", "z", dst.URL), MigrateOptions{
+		RenameVhosts: map[string]string{"tenant": "customer"},
+		Mode:         MigrateOverwrite,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"PUT /api/vhosts/skipped",
+		"PUT /api/users/app",
+		"PUT /api/users/other",
+		"PUT /api/permissions/customer/app",
+		"PUT /api/permissions/skipped/other",
+		"PUT /api/exchanges/customer/events",
+		"DELETE /api/exchanges/customer/audit",
+		"PUT /api/exchanges/customer/audit",
+		"PUT /api/exchanges/skipped/other",
+		"POST /api/bindings/customer/e/events/q/jobs",
+		"PUT /api/policies/customer/ttl",
+	}
+
+	if got := requests(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests\n%q\nwant\n%q", got, want)
+	}
+
+	if !strings.Contains(report.String(), "create user admin: skipped (credentials of the destination)") {
+		t.Errorf("destination user not skipped:\n%s", report)
+	}
+
+	if len(report.Failed()) != 0 {
+		t.Errorf("unexpected failed steps %v", report.Failed())
+	}
+}
+
+func TestRabbit_PlanMigrationHashingAlgorithm(t *testing.T) {
+	source := migrationSource(Definitions{Users: []User{
+		{Name: "app", PasswordHash: "b"},
+		{Name: "other", PasswordHash: "c", HashingAlgorithm: HashingSHA256},
+	}}, MigrateOptions{})
+	current := Definitions{Users: []User{
+		{Name: "app", PasswordHash: "b", HashingAlgorithm: HashingSHA256},
+		{Name: "other", PasswordHash: "c", HashingAlgorithm: HashingSHA256},
+	}}
+
+	steps := planMigration(source, current, "4.0.5", MigrateOverwrite, "admin")
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %v", steps)
+	}
+
+	if steps[0].SkipReason != "" {
+		t.Errorf("user with a different hashing algorithm was skipped (%s)", steps[0].SkipReason)
+	}
+	if steps[1].SkipReason != "unchanged" {
+		t.Errorf("identical user was not skipped: %+v", steps[1])
+	}
+}
+
+func TestRabbit_PlanMigrationMirroring(t *testing.T) {
+	source := Definitions{Policies: []Policy{
+		{Vhost: "tenant", Name: "ha", Pattern: ".*", Definition: map[string]interface{}{"ha-mode": "all", "ha-sync-mode": "automatic"}},
+		{Vhost: "tenant", Name: "ttl", Pattern: "^jobs$", Definition: map[string]interface{}{"message-ttl": 60000}},
+	}}
+
+	steps := planMigration(source, Definitions{}, "4.0.5", MigrateOverwrite, "admin")
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %v", steps)
+	}
+
+	want := "classic queue mirroring (ha-mode, ha-sync-mode) is not supported by RabbitMQ 4.0.5"
+	if steps[0].SkipReason != want {
+		t.Errorf("unexpected skip reason %q", steps[0].SkipReason)
+	}
+	if steps[1].SkipReason != "" {
+		t.Errorf("policy without mirroring was skipped (%s)", steps[1].SkipReason)
+	}
+
+	for _, step := range planMigration(source, Definitions{}, "3.13.7", MigrateOverwrite, "admin") {
+		if step.SkipReason != "" {
+			t.Errorf("policy %s skipped for a 3.x destination (%s)", step.Name, step.SkipReason)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+)
+
+type Node struct {
+	DiskFree      int64    `json:"disk_free"`
+	DiskFreeAlarm bool     `json:"disk_free_alarm"`
+	DiskFreeLimit int64    `json:"disk_free_limit"`
+	FdTotal       int      `json:"fd_total"`
+	FdUsed        int      `json:"fd_used"`
+	MemAlarm      bool     `json:"mem_alarm"`
+	MemLimit      int64    `json:"mem_limit"`
+	MemUsed       int64    `json:"mem_used"`
+	Name          string   `json:"name"`
+	Partitions    []string `json:"partitions"`
+	ProcTotal     int      `json:"proc_total"`
+	ProcUsed      int      `json:"proc_used"`
+	Running       bool     `json:"running"`
+	SocketsTotal  int      `json:"sockets_total"`
+	SocketsUsed   int      `json:"sockets_used"`
+	Type          string   `json:"type"`   // disc or ram
+	Uptime        int64    `json:"uptime"` // milliseconds
+}
+
+// GetNodes returns a list of the nodes of the cluster.
+func (r *Rabbit) GetNodes() ([]Node, error) {
+	body, err := r.doRequest("GET", "/api/nodes", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]Node, 0)
+	err = json.Unmarshal(body, &nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// GetNode returns an individual node.
+func (r *Rabbit) GetNode(name string) (Node, error) {
+	body, err := r.doRequest("GET", "/api/nodes/"+name, nil)
+	if err != nil {
+		return Node{}, err
+	}
+
+	node := Node{}
+	err = json.Unmarshal(body, &node)
+	if err != nil {
+		return Node{}, err
+	}
+
+	return node, nil
+}
@@ -0,0 +1,26 @@
+package rabbitapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRabbit_GetNodes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/nodes" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		w.Write([]byte(`[{"name":"rabbit@a","running":true,"type":"disc","mem_used":1024,"mem_limit":4096,"mem_alarm":false,"disk_free":100,"disk_free_limit":50,"disk_free_alarm":true,"fd_used":10,"fd_total":1024,"uptime":60000,"partitions":[]}]`))
+	}))
+	defer ts.Close()
+
+	nodes, err := Auth("guest", "guest", ts.URL).GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 1 || nodes[0].Name != "rabbit@a" || !nodes[0].Running || nodes[0].MemUsed != 1024 || !nodes[0].DiskFreeAlarm {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+}
@@ -0,0 +1,57 @@
+// Package otelhook records the requests of a rabbitapi client as
+// OpenTelemetry client spans, named after the method and endpoint template,
+// e.g. "GET /api/exchanges/{vhost}/{name}".
+//
+//	r.Hooks = append(r.Hooks, otelhook.New(otel.GetTracerProvider()))
+//
+// The client api has no context parameters, so the spans are not children of
+// the caller's span.
+package otelhook
+
+import (
+	"context"
+
+	"github.com/koding/rabbitapi"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/koding/rabbitapi"
+
+// Hook is a rabbitapi.Hook creating OpenTelemetry spans.
+type Hook struct {
+	tracer trace.Tracer
+}
+
+// New returns a hook creating spans with a tracer of tp.
+func New(tp trace.TracerProvider) *Hook {
+	return &Hook{tracer: tp.Tracer(tracerName)}
+}
+
+func (h *Hook) RequestDone(info rabbitapi.RequestInfo) {
+	_, span := h.tracer.Start(context.Background(), info.Method+" "+info.Endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(info.Start),
+		trace.WithAttributes(
+			attribute.String("http.request.method", info.Method),
+			attribute.String("http.route", info.Endpoint),
+			attribute.String("url.path", info.Path),
+		),
+	)
+
+	if info.Query != "" {
+		span.SetAttributes(attribute.String("url.query", info.Query))
+	}
+
+	if info.StatusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", info.StatusCode))
+	}
+
+	if info.Err != nil {
+		span.RecordError(info.Err)
+		span.SetStatus(codes.Error, info.Err.Error())
+	}
+
+	span.End(trace.WithTimestamp(info.Start.Add(info.Duration)))
+}
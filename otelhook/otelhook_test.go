@@ -0,0 +1,43 @@
+package otelhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/koding/rabbitapi"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRabbit_OtelHook(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	start := time.Now()
+	hook := New(tp)
+	hook.RequestDone(rabbitapi.RequestInfo{
+		Method:     "DELETE",
+		Endpoint:   "/api/vhosts/{name}",
+		Path:       "/api/vhosts/tenant",
+		StatusCode: 404,
+		Start:      start,
+		Duration:   time.Second,
+		Err:        errors.New("404 Not Found"),
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "DELETE /api/vhosts/{name}" || span.Status().Code != codes.Error {
+		t.Errorf("unexpected span %s %v", span.Name(), span.Status())
+	}
+
+	if d := span.EndTime().Sub(span.StartTime()); d != time.Second {
+		t.Errorf("unexpected span duration %s", d)
+	}
+}
@@ -0,0 +1,211 @@
+package rabbitapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListOptions selects a page of a list endpoint. The broker filters, sorts and
+// paginates the list, so only the requested page is transferred.
+type ListOptions struct {
+	// Page is the page to return, starting at 1. Zero means the first page.
+	Page int
+
+	// PageSize is the number of items per page. Zero means 100, the broker
+	// allows at most 500.
+	PageSize int
+
+	// Name only returns items whose name contains Name, or matches it if
+	// UseRegex is set.
+	Name     string
+	UseRegex bool
+
+	// Sort is the field to sort by, e.g. "name" or "message_stats.publish".
+	Sort        string
+	SortReverse bool
+
+	// Columns restricts the returned fields of every item, e.g.
+	// []string{"name", "vhost"}. Fields which are not selected are left
+	// empty.
+	Columns []string
+}
+
+func (o ListOptions) query() string {
+	page := o.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	pageSize := o.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+
+	v := url.Values{}
+	v.Set("page", strconv.Itoa(page))
+	v.Set("page_size", strconv.Itoa(pageSize))
+	if o.Name != "" {
+		v.Set("name", o.Name)
+		v.Set("use_regex", strconv.FormatBool(o.UseRegex))
+	}
+	if o.Sort != "" {
+		v.Set("sort", o.Sort)
+		v.Set("sort_reverse", strconv.FormatBool(o.SortReverse))
+	}
+	if len(o.Columns) != 0 {
+		v.Set("columns", strings.Join(o.Columns, ","))
+	}
+
+	return v.Encode()
+}
+
+// Page is a single page of a list endpoint.
+type Page[T any] struct {
+	Items         []T `json:"items"`
+	Page          int `json:"page"`
+	PageCount     int `json:"page_count"`
+	PageSize      int `json:"page_size"`
+	ItemCount     int `json:"item_count"`
+	FilteredCount int `json:"filtered_count"`
+	TotalCount    int `json:"total_count"`
+}
+
+// getPage returns a single page of the list endpoint. Endpoints the broker
+// doesn't paginate return the whole list as the only page.
+func getPage[T any](r *Rabbit, endpoint string, opts ListOptions) (Page[T], error) {
+	body, err := r.doRequest("GET", endpoint+"?"+opts.query(), nil)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	if body = bytes.TrimSpace(body); len(body) != 0 && body[0] == '[' {
+		items := make([]T, 0)
+		if err := json.Unmarshal(body, &items); err != nil {
+			return Page[T]{}, err
+		}
+
+		return Page[T]{
+			Items:         items,
+			Page:          1,
+			PageCount:     1,
+			PageSize:      len(items),
+			ItemCount:     len(items),
+			FilteredCount: len(items),
+			TotalCount:    len(items),
+		}, nil
+	}
+
+	page := Page[T]{}
+	err = json.Unmarshal(body, &page)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	return page, nil
+}
+
+// allPages returns an iterator over the items of every page, starting at
+// opts.Page. Pages are fetched lazily, when the items of the previous page
+// have been consumed. Iteration stops after the first error.
+func allPages[T any](r *Rabbit, endpoint string, opts ListOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if opts.Page <= 0 {
+			opts.Page = 1
+		}
+
+		for {
+			page, err := getPage[T](r, endpoint, opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if page.Page >= page.PageCount || len(page.Items) == 0 {
+				return
+			}
+
+			opts.Page = page.Page + 1
+		}
+	}
+}
+
+// ListExchanges returns a page of all exchanges.
+func (r *Rabbit) ListExchanges(opts ListOptions) (Page[Exchange], error) {
+	return getPage[Exchange](r, "/api/exchanges", opts)
+}
+
+// IterExchanges iterates over all exchanges, page by page.
+func (r *Rabbit) IterExchanges(opts ListOptions) iter.Seq2[Exchange, error] {
+	return allPages[Exchange](r, "/api/exchanges", opts)
+}
+
+// ListVhostExchanges returns a page of all exchanges in a given virtual host.
+func (r *Rabbit) ListVhostExchanges(vhost string, opts ListOptions) (Page[Exchange], error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	return getPage[Exchange](r, "/api/exchanges/"+vhost, opts)
+}
+
+// ListQueues returns a page of all queues.
+func (r *Rabbit) ListQueues(opts ListOptions) (Page[Queue], error) {
+	return getPage[Queue](r, "/api/queues", opts)
+}
+
+// IterQueues iterates over all queues, page by page.
+func (r *Rabbit) IterQueues(opts ListOptions) iter.Seq2[Queue, error] {
+	return allPages[Queue](r, "/api/queues", opts)
+}
+
+// ListVhostQueues returns a page of all queues in a given virtual host.
+func (r *Rabbit) ListVhostQueues(vhost string, opts ListOptions) (Page[Queue], error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	return getPage[Queue](r, "/api/queues/"+vhost, opts)
+}
+
+// ListConnections returns a page of all open connections.
+func (r *Rabbit) ListConnections(opts ListOptions) (Page[Connection], error) {
+	return getPage[Connection](r, "/api/connections", opts)
+}
+
+// IterConnections iterates over all open connections, page by page.
+func (r *Rabbit) IterConnections(opts ListOptions) iter.Seq2[Connection, error] {
+	return allPages[Connection](r, "/api/connections", opts)
+}
+
+// ListUsers returns a page of all users. Brokers which don't paginate users
+// return all users as a single page.
+func (r *Rabbit) ListUsers(opts ListOptions) (Page[User], error) {
+	return getPage[User](r, "/api/users", opts)
+}
+
+// IterUsers iterates over all users, page by page.
+func (r *Rabbit) IterUsers(opts ListOptions) iter.Seq2[User, error] {
+	return allPages[User](r, "/api/users", opts)
+}
+
+// ListVhosts returns a page of all vhosts. Brokers which don't paginate vhosts
+// return all vhosts as a single page.
+func (r *Rabbit) ListVhosts(opts ListOptions) (Page[Vhost], error) {
+	return getPage[Vhost](r, "/api/vhosts", opts)
+}
+
+// IterVhosts iterates over all vhosts, page by page.
+func (r *Rabbit) IterVhosts(opts ListOptions) iter.Seq2[Vhost, error] {
+	return allPages[Vhost](r, "/api/vhosts", opts)
+}
@@ -0,0 +1,67 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestRabbit_IterQueues(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		queries = append(queries, req.URL.RawQuery)
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		fmt.Fprintf(w, `{"items":[{"name":"q%d-a"},{"name":"q%d-b"}],"page":%d,"page_count":3,"page_size":2,"item_count":2,"filtered_count":6,"total_count":8}`, page, page, page)
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	opts := ListOptions{PageSize: 2, Name: "^q", UseRegex: true, Sort: "name", Columns: []string{"name"}}
+
+	page, err := r.ListQueues(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 8 || page.FilteredCount != 6 || len(page.Items) != 2 {
+		t.Errorf("unexpected page %+v", page)
+	}
+
+	expected := "columns=name&name=%5Eq&page=1&page_size=2&sort=name&sort_reverse=false&use_regex=true"
+	if queries[0] != expected {
+		t.Errorf("unexpected query %s", queries[0])
+	}
+
+	names := make([]string, 0)
+	for queue, err := range r.IterQueues(opts) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, queue.Name)
+		if queue.Name == "q2-b" {
+			break
+		}
+	}
+
+	if len(names) != 4 || len(queries) != 3 {
+		t.Errorf("expected 4 queues from 2 pages, got %v with queries %v", names, queries)
+	}
+}
+
+func TestRabbit_ListUsersUnpaginated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"name":"guest","tags":"administrator"},{"name":"app","tags":""}]`))
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	page, err := r.ListUsers(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.PageCount != 1 || page.TotalCount != 2 || page.Items[1].Name != "app" {
+		t.Errorf("unexpected page %+v", page)
+	}
+}
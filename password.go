@@ -0,0 +1,63 @@
+package rabbitapi
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+)
+
+// Password hashing algorithms supported by the broker. They are used as the
+// hashing_algorithm of a user.
+const (
+	HashingSHA256 = "rabbit_password_hashing_sha256"
+	HashingSHA512 = "rabbit_password_hashing_sha512"
+	HashingMD5    = "rabbit_password_hashing_md5"
+)
+
+// HashPassword hashes the password the same way the broker does, so it can be
+// passed to CreateUserWithHash without the plaintext password ever being
+// sent. A random 4 byte salt is prepended to the password, the result is
+// hashed with the given algorithm and the salt followed by the hash is
+// returned base64 encoded. An empty algorithm means HashingSHA256.
+func HashPassword(password, algorithm string) (string, error) {
+	salt := make([]byte, 4)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	return hashPassword(salt, password, algorithm)
+}
+
+func hashPassword(salt []byte, password, algorithm string) (string, error) {
+	var h hash.Hash
+	switch hashingAlgorithm(algorithm) {
+	case HashingSHA256:
+		h = sha256.New()
+	case HashingSHA512:
+		h = sha512.New()
+	case HashingMD5:
+		h = md5.New()
+	default:
+		return "", fmt.Errorf("unknown hashing algorithm '%s'", algorithm)
+	}
+
+	h.Write(salt)
+	h.Write([]byte(password))
+
+	salted := append(append([]byte{}, salt...), h.Sum(nil)...)
+	return base64.StdEncoding.EncodeToString(salted), nil
+}
+
+// hashingAlgorithm resolves the empty algorithm to HashingSHA256, the one
+// HashPassword uses for it.
+func hashingAlgorithm(algorithm string) string {
+	if algorithm == "" {
+		return HashingSHA256
+	}
+
+	return algorithm
+}
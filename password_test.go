@@ -0,0 +1,34 @@
+package rabbitapi
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestRabbit_HashPassword(t *testing.T) {
+	// example from http://www.rabbitmq.com/passwords.html
+	hash, err := hashPassword([]byte{0x90, 0x8d, 0xc6, 0x0a}, "test12", HashingSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash != "kI3GCqW5JLMJa4iX1lo7X4D6XbYqlLgxIs30+P6tENUV2POR" {
+		t.Errorf("unexpected hash %s", hash)
+	}
+
+	for algorithm, size := range map[string]int{HashingSHA256: 36, HashingSHA512: 68, HashingMD5: 20} {
+		hash, err := HashPassword("secret", algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := base64.StdEncoding.DecodeString(hash)
+		if err != nil || len(data) != size {
+			t.Errorf("%s: expected %d bytes, got %d (%v)", algorithm, size, len(data), err)
+		}
+	}
+
+	if _, err := HashPassword("secret", "bcrypt"); err == nil {
+		t.Error("expected an error for an unknown algorithm")
+	}
+}
@@ -91,3 +91,27 @@ func (r *Rabbit) DeletePermission(vhost, user string) error {
 	return nil
 
 }
+
+type TopicPermission struct {
+	Exchange string `json:"exchange"`
+	Read     string `json:"read"`
+	User     string `json:"user"`
+	Vhost    string `json:"vhost"`
+	Write    string `json:"write"`
+}
+
+// GetTopicPermissions returns a list of all topic permissions for all users.
+func (r *Rabbit) GetTopicPermissions() ([]TopicPermission, error) {
+	body, err := r.doRequest("GET", "/api/topic-permissions", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]TopicPermission, 0)
+	err = json.Unmarshal(body, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
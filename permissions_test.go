@@ -28,7 +28,7 @@ func TestRabbit_CreatePermission(t *testing.T) {
 	r := Auth("guest", "guest", "http://localhost:15672")
 
 	// Needed for creating permissions
-	err := r.CreateUser("zeynep", "deneme", "")
+	err := r.CreateUser("zeynep", "deneme", nil)
 	if err != nil {
 		t.Error(err)
 	} else {
@@ -53,3 +53,13 @@ func TestRabbit_DeletePermission(t *testing.T) {
 		t.Log("permission for user 'zeynep' is deleted successfull")
 	}
 }
+
+func TestRabbit_GetTopicPermissions(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	permissions, err := r.GetTopicPermissions()
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("Topic permissions:", permissions)
+	}
+}
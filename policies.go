@@ -0,0 +1,112 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+)
+
+type Policy struct {
+	ApplyTo    string                 `json:"apply-to"`
+	Definition map[string]interface{} `json:"definition"`
+	Name       string                 `json:"name"`
+	Pattern    string                 `json:"pattern"`
+	Priority   int                    `json:"priority"`
+	Vhost      string                 `json:"vhost"`
+}
+
+// GetPolicies returns a list of all policies.
+func (r *Rabbit) GetPolicies() ([]Policy, error) {
+	body, err := r.doRequest("GET", "/api/policies", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]Policy, 0)
+	err = json.Unmarshal(body, &policies)
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// GetVhostPolicies returns a list of all policies in a given virtual host.
+func (r *Rabbit) GetVhostPolicies(vhost string) ([]Policy, error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	body, err := r.doRequest("GET", "/api/policies/"+vhost, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]Policy, 0)
+	err = json.Unmarshal(body, &policies)
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// GetPolicy returns an individual policy for the given vhost and name.
+func (r *Rabbit) GetPolicy(vhost, name string) (Policy, error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	body, err := r.doRequest("GET", "/api/policies/"+vhost+"/"+name, nil)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	policy := Policy{}
+	err = json.Unmarshal(body, &policy)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	return policy, nil
+}
+
+// CreatePolicy creates or updates a policy for the given vhost and name.
+// applyTo is one of "queues", "exchanges" or "all". For more info please look
+// at: http://www.rabbitmq.com/parameters.html#policies
+func (r *Rabbit) CreatePolicy(vhost, name, pattern, applyTo string, definition map[string]interface{}, priority int) error {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	policy := &Policy{
+		Pattern:    pattern,
+		ApplyTo:    applyTo,
+		Definition: definition,
+		Priority:   priority,
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.doRequest("PUT", "/api/policies/"+vhost+"/"+name, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeletePolicy deletes an individual policy for the given vhost and name.
+func (r *Rabbit) DeletePolicy(vhost, name string) error {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	_, err := r.doRequest("DELETE", "/api/policies/"+vhost+"/"+name, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
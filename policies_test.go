@@ -0,0 +1,36 @@
+package rabbitapi
+
+import (
+	"testing"
+)
+
+func TestRabbit_CreatePolicy(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	definition := map[string]interface{}{"message-ttl": 60000}
+	err := r.CreatePolicy("/", "rabbitapi", "^rabbitapi\\.", "queues", definition, 0)
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("policy 'rabbitapi' is created successfull")
+	}
+}
+
+func TestRabbit_GetPolicy(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	policy, err := r.GetPolicy("/", "rabbitapi")
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("policy 'rabbitapi':", policy)
+	}
+}
+
+func TestRabbit_DeletePolicy(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	err := r.DeletePolicy("/", "rabbitapi")
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("policy 'rabbitapi' is deleted successfully")
+	}
+}
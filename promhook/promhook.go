@@ -0,0 +1,61 @@
+// Package promhook records the requests of a rabbitapi client as Prometheus
+// metrics:
+//
+//	rabbitapi_requests_total{method, endpoint, status}
+//	rabbitapi_request_duration_seconds{method, endpoint}
+//
+// endpoint is the endpoint template, e.g. /api/exchanges/{vhost}/{name}, and
+// status the HTTP status code, or "error" if no response was received.
+//
+//	hook, err := promhook.New(prometheus.DefaultRegisterer)
+//	r.Hooks = append(r.Hooks, hook)
+package promhook
+
+import (
+	"strconv"
+
+	"github.com/koding/rabbitapi"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Hook is a rabbitapi.Hook updating Prometheus metrics.
+type Hook struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// New returns a hook whose metrics are registered with reg.
+func New(reg prometheus.Registerer) (*Hook, error) {
+	h := &Hook{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rabbitapi_requests_total",
+			Help: "Requests sent to the RabbitMQ management api.",
+		}, []string{"method", "endpoint", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "rabbitapi_request_duration_seconds",
+			Help:    "Duration of requests sent to the RabbitMQ management api.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "endpoint"}),
+	}
+
+	if err := reg.Register(h.requests); err != nil {
+		return nil, err
+	}
+
+	if err := reg.Register(h.duration); err != nil {
+		reg.Unregister(h.requests)
+		return nil, err
+	}
+
+	return h, nil
+}
+
+func (h *Hook) RequestDone(info rabbitapi.RequestInfo) {
+	status := "error"
+	if info.StatusCode != 0 {
+		status = strconv.Itoa(info.StatusCode)
+	}
+
+	h.requests.WithLabelValues(info.Method, info.Endpoint, status).Inc()
+	h.duration.WithLabelValues(info.Method, info.Endpoint).Observe(info.Duration.Seconds())
+}
@@ -0,0 +1,42 @@
+package promhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/koding/rabbitapi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRabbit_PromHook(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	hook, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook.RequestDone(rabbitapi.RequestInfo{Method: "GET", Endpoint: "/api/vhosts/{name}", StatusCode: 200, Duration: time.Millisecond})
+	hook.RequestDone(rabbitapi.RequestInfo{Method: "GET", Endpoint: "/api/vhosts/{name}", StatusCode: 200, Duration: time.Millisecond})
+	hook.RequestDone(rabbitapi.RequestInfo{Method: "PUT", Endpoint: "/api/vhosts/{name}", Err: errors.New("connection refused")})
+
+	want := `
+# HELP rabbitapi_requests_total Requests sent to the RabbitMQ management api.
+# TYPE rabbitapi_requests_total counter
+rabbitapi_requests_total{endpoint="/api/vhosts/{name}",method="GET",status="200"} 2
+rabbitapi_requests_total{endpoint="/api/vhosts/{name}",method="PUT",status="error"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "rabbitapi_requests_total"); err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(hook.duration); n != 2 {
+		t.Errorf("expected 2 duration series, got %d", n)
+	}
+
+	if _, err := New(reg); err == nil {
+		t.Error("expected an error registering twice")
+	}
+}
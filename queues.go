@@ -0,0 +1,123 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+)
+
+type Queue struct {
+	Arguments                     map[string]interface{} `json:"arguments"`
+	AutoDelete                    bool                   `json:"auto_delete"`
+	Consumers                     int                    `json:"consumers"`
+	Durable                       bool                   `json:"durable"`
+	MessageStats                  MessageStats           `json:"message_stats"`
+	Messages                      int                    `json:"messages"`
+	MessagesDetails               RateDetails            `json:"messages_details"`
+	MessagesReady                 int                    `json:"messages_ready"`
+	MessagesReadyDetails          RateDetails            `json:"messages_ready_details"`
+	MessagesUnacknowledged        int                    `json:"messages_unacknowledged"`
+	MessagesUnacknowledgedDetails RateDetails            `json:"messages_unacknowledged_details"`
+	Name                          string                 `json:"name"`
+	Node                          string                 `json:"node"`
+	Policy                        string                 `json:"policy"`
+	Vhost                         string                 `json:"vhost"`
+}
+
+// GetQueues returns a list of all queues.
+func (r *Rabbit) GetQueues() ([]Queue, error) {
+	body, err := r.doRequest("GET", "/api/queues", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	queues := make([]Queue, 0)
+	err = json.Unmarshal(body, &queues)
+	if err != nil {
+		return nil, err
+	}
+
+	return queues, nil
+}
+
+// GetVhostQueues returns a list of all queues in a given virtual host.
+func (r *Rabbit) GetVhostQueues(vhost string) ([]Queue, error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	body, err := r.doRequest("GET", "/api/queues/"+vhost, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	queues := make([]Queue, 0)
+	err = json.Unmarshal(body, &queues)
+	if err != nil {
+		return nil, err
+	}
+
+	return queues, nil
+}
+
+// GetQueue returns an individual queue for the given vhost and name. Samples
+// of its lengths and message rates can be requested with StatsOptions.
+func (r *Rabbit) GetQueue(vhost, name string, opts ...StatsOptions) (Queue, error) {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	body, err := r.doRequest("GET", "/api/queues/"+vhost+"/"+name+statsQuery(opts), nil)
+	if err != nil {
+		return Queue{}, err
+	}
+
+	queue := Queue{}
+	err = json.Unmarshal(body, &queue)
+	if err != nil {
+		return Queue{}, err
+	}
+
+	return queue, nil
+}
+
+// CreateQueue creates an individual queue for the given vhost and name.
+func (r *Rabbit) CreateQueue(vhost, name string, durable, autoDelete bool, args map[string]interface{}) error {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	if args == nil {
+		args = make(map[string]interface{}, 0)
+	}
+
+	queue := map[string]interface{}{
+		"durable":     durable,
+		"auto_delete": autoDelete,
+		"arguments":   args,
+	}
+
+	data, err := json.Marshal(queue)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.doRequest("PUT", "/api/queues/"+vhost+"/"+name, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteQueue deletes an individual queue for the given vhost and name.
+func (r *Rabbit) DeleteQueue(vhost, name string) error {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	_, err := r.doRequest("DELETE", "/api/queues/"+vhost+"/"+name, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
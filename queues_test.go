@@ -0,0 +1,45 @@
+package rabbitapi
+
+import (
+	"testing"
+)
+
+func TestRabbit_CreateQueue(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	err := r.CreateQueue("/", "rabbitapi", false, true, nil)
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("queue with name 'rabbitapi' is created successfull")
+	}
+}
+
+func TestRabbit_GetQueues(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	queues, err := r.GetQueues()
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("queues:", queues)
+	}
+}
+
+func TestRabbit_GetQueue(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	queue, err := r.GetQueue("/", "rabbitapi")
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("queue 'rabbitapi':", queue)
+	}
+}
+
+func TestRabbit_DeleteQueue(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	err := r.DeleteQueue("/", "rabbitapi")
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("queue 'rabbitapi' is deleted successfully")
+	}
+}
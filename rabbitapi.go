@@ -7,16 +7,29 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type Rabbit struct {
 	Username string
 	Password string
 	Url      string
+
+	// Retry enables retrying of idempotent (GET, PUT, DELETE) requests. It's
+	// nil by default, which means requests are tried only once.
+	Retry *RetryPolicy
+
+	// Hooks are called after every request sent to the management api, e.g.
+	// for metrics or tracing. See promhook and otelhook for adapters.
+	Hooks []Hook
+
+	// readOnly and dryRun are set on the clients returned by ReadOnlyClient
+	// and DryRunClient.
+	readOnly bool
+	dryRun   *RequestLog
 }
 
 type Status struct {
@@ -24,11 +37,13 @@ type Status struct {
 }
 
 type Overview struct {
-	Contexts []struct {
-		Description string `json:"description"`
-		Node        string `json:"node"`
-		Path        string `json:"path"`
-		Port        int    `json:"port"`
+	ChurnRates  ChurnRates `json:"churn_rates"`
+	ClusterName string     `json:"cluster_name"`
+	Contexts    []struct {
+		Description string      `json:"description"`
+		Node        string      `json:"node"`
+		Path        string      `json:"path"`
+		Port        json.Number `json:"port"` // a string on newer brokers
 	} `json:"contexts"`
 	ErlangVersion string `json:"erlang_version"`
 	ExchangeTypes []struct {
@@ -42,35 +57,23 @@ type Overview struct {
 		Port     int    `json:"port"`
 		Protocol string `json:"protocol"`
 	} `json:"listeners"`
-	ManagementVersion string   `json:"management_version"`
-	MessageStats      []string `json:"message_stats"`
-	Node              string   `json:"node"`
+	ManagementVersion string       `json:"management_version"`
+	MessageStats      MessageStats `json:"message_stats"`
+	Node              string       `json:"node"`
 	ObjectTotals      struct {
 		Channels    int `json:"channels"`
-		Connections int `json:"connections`
-		Consumers   int `json:"consumers`
-		Exchanges   int `json:"exchanges`
-		Queues      int `json:"queues`
+		Connections int `json:"connections"`
+		Consumers   int `json:"consumers"`
+		Exchanges   int `json:"exchanges"`
+		Queues      int `json:"queues"`
 	} `json:"object_totals"`
 	QueueTotals struct {
-		Messages        int `json:"messages"`
-		MessagesDetails struct {
-			Interval  int     `json:"interval"`
-			LastEvent int     `json:"last_event"`
-			Rate      float64 `json:"rate"`
-		} `json:"messages_details"`
-		MessagesReady        int `json:"messages_ready"`
-		MessagesReadyDetails struct {
-			Interval  int     `json:"interval"`
-			LastEvent int     `json:"last_event"`
-			Rate      float64 `json:"rate"`
-		} `json:"messages_ready_details"`
-		MessagesUnacknowledged        int `json:"messages_unacknowledged"`
-		MessagesUnacknowledgedDetails struct {
-			Interval  int     `json:"interval"`
-			LastEvent int     `json:"last_event"`
-			Rate      float64 `json:"rate"`
-		} `json:"messages_unacknowledged_details"`
+		Messages                      int         `json:"messages"`
+		MessagesDetails               RateDetails `json:"messages_details"`
+		MessagesReady                 int         `json:"messages_ready"`
+		MessagesReadyDetails          RateDetails `json:"messages_ready_details"`
+		MessagesUnacknowledged        int         `json:"messages_unacknowledged"`
+		MessagesUnacknowledgedDetails RateDetails `json:"messages_unacknowledged_details"`
 	} `json:"queue_totals"`
 	RabbitmqVersion  string `json:"rabbitmq_version"`
 	StatisticsDbNode string `json:"statistics_db_node"`
@@ -104,9 +107,10 @@ func Auth(username, password, url string) *Rabbit {
 }
 
 // Various random bits of information that describe the whole system, like
-// number of exchanges, connection information, erlang version, etc..
-func (r *Rabbit) GetOverview() (Overview, error) {
-	body, err := r.doRequest("GET", "/api/overview", nil)
+// number of exchanges, connection information, erlang version, etc.. Samples
+// of queue totals and message stats can be requested with StatsOptions.
+func (r *Rabbit) GetOverview(opts ...StatsOptions) (Overview, error) {
+	body, err := r.doRequest("GET", "/api/overview"+statsQuery(opts), nil)
 	if err != nil {
 		return Overview{}, err
 	}
@@ -148,41 +152,134 @@ func (r *Rabbit) AlivenessTest(vhost string) error {
 
 }
 
-// Our custom HTTP Request wrapper
+// APIError is returned when the management api responds with an unexpected
+// status code.
+type APIError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	Status     string
+}
+
+func (e *APIError) Error() string {
+	return e.Status
+}
+
+// IsNotFound reports whether err is an APIError for an object which doesn't
+// exist.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Our custom HTTP Request wrapper. Idempotent requests are retried according
+// to r.Retry, if set.
 func (r *Rabbit) doRequest(method, endpoint string, body []byte) ([]byte, error) {
+	if r.Retry == nil || !isIdempotent(method) {
+		return r.do(method, endpoint, body)
+	}
+
+	var responseBody []byte
+	err := r.Retry.do(func() error {
+		var err error
+		responseBody, err = r.do(method, endpoint, body)
+		return err
+	})
+
+	return responseBody, err
+}
+
+// do sends a single request to the management api and returns the response
+// body of GET requests.
+func (r *Rabbit) do(method, endpoint string, body []byte) ([]byte, error) {
+	resp, err := r.send(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if method != "GET" {
+		return nil, nil
+	}
+
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseBody, nil
+}
+
+// send sends a single request to the management api and checks the status of
+// the response. The caller must close the response body.
+func (r *Rabbit) send(method, endpoint string, body []byte) (*http.Response, error) {
+	if r.readOnly && changes(method, endpoint) {
+		return nil, fmt.Errorf("%s %s: %w", method, endpoint, ErrReadOnly)
+	}
+
+	if r.dryRun != nil && method != "GET" {
+		return r.dryRun.record(method, endpoint, body), nil
+	}
+
+	if len(r.Hooks) == 0 {
+		return r.roundTrip(method, endpoint, body)
+	}
+
+	start := time.Now()
+	resp, err := r.roundTrip(method, endpoint, body)
+	r.callHooks(method, endpoint, start, resp, err)
+
+	return resp, err
+}
+
+// roundTrip does the work of send.
+func (r *Rabbit) roundTrip(method, endpoint string, body []byte) (*http.Response, error) {
+	switch method {
+	case "GET", "PUT", "DELETE", "POST":
+	default:
+		return nil, errors.New("Method is not supported")
+	}
+
 	readerBody := bytes.NewBuffer(body)
 	req, err := r.newRequest(method, endpoint, readerBody)
 	if err != nil {
-		log.Println(err)
+		return nil, err
 	}
 	req.SetBasicAuth(r.Username, r.Password)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Println(err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	switch method {
-	case "PUT", "DELETE":
-		if resp.StatusCode != 204 {
-			return nil, fmt.Errorf(resp.Status)
-		}
-		return nil, nil
-	case "GET":
-		if resp.StatusCode != 200 {
-			return nil, fmt.Errorf(resp.Status)
-		}
+	if !expectedStatus(method, resp.StatusCode) {
+		resp.Body.Close()
+		return nil, newAPIError(method, endpoint, resp)
+	}
 
-		responseBody, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
+	return resp, nil
+}
 
-		return responseBody, nil
+// expectedStatus reports whether code is the status of a successful request
+// with the given method.
+func expectedStatus(method string, code int) bool {
+	switch method {
+	case "PUT", "DELETE":
+		return code == 204
+	case "POST":
+		return code == 201 || code == 204
 	default:
-		return nil, errors.New("Method is not supported")
+		return code == 200
+	}
+}
+
+func newAPIError(method, endpoint string, resp *http.Response) *APIError {
+	return &APIError{
+		Method:     method,
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
 	}
 }
 
@@ -195,7 +292,7 @@ func (r *Rabbit) newRequest(method, endpoint string, body io.Reader) (*http.Requ
 		return nil, err
 	}
 
-	u.Opaque = endpoint // get around the path encoding bug
+	u.Opaque = strings.SplitN(endpoint, "?", 2)[0] // get around the path encoding bug
 	rc, ok := body.(io.ReadCloser)
 	if !ok && body != nil {
 		rc = ioutil.NopCloser(body)
@@ -1,6 +1,8 @@
 package rabbitapi
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"testing"
 )
 
@@ -23,3 +25,62 @@ func TestRabbit_OverviewTest(t *testing.T) {
 		t.Log("overview struct is", overview)
 	}
 }
+
+// The testdata/overview-*.json fixtures are not captured from real brokers:
+// they are written after the responses documented for the given versions.
+// Replace them with captured bodies when a 3.x and a 4.x broker are at hand.
+func TestRabbit_DecodeOverview(t *testing.T) {
+	tests := []struct {
+		file        string
+		version     string
+		clusterName string
+		publish     int64
+		queues      int
+		port        string
+	}{
+		{"testdata/overview-3.1.json", "3.1.5", "", 0, 0, "15672"},
+		{"testdata/overview-3.8.json", "3.8.9", "rabbit@staging-1", 1250, 4, "15672"},
+		{"testdata/overview-3.12.json", "3.12.13", "rabbit@prod-1.example.com", 981510, 18, "15672"},
+		{"testdata/overview-4.0.json", "4.0.5", "rabbit@prod-2.example.com", 50400, 7, "15672"},
+	}
+
+	for _, test := range tests {
+		data, err := ioutil.ReadFile(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		overview := Overview{}
+		if err := json.Unmarshal(data, &overview); err != nil {
+			t.Errorf("%s: %s", test.file, err)
+			continue
+		}
+
+		if overview.RabbitmqVersion != test.version ||
+			overview.ClusterName != test.clusterName ||
+			overview.MessageStats.Publish != test.publish ||
+			overview.ObjectTotals.Queues != test.queues ||
+			overview.Contexts[0].Port.String() != test.port {
+			t.Errorf("%s: unexpected overview %+v", test.file, overview)
+		}
+	}
+
+	data, err := ioutil.ReadFile("testdata/overview-3.12.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overview := Overview{}
+	if err := json.Unmarshal(data, &overview); err != nil {
+		t.Fatal(err)
+	}
+
+	ack := overview.MessageStats.AckDetails
+	if ack.Rate != 153.2 || len(ack.Samples) != 2 || ack.Samples[0].Sample != 981273 {
+		t.Errorf("unexpected ack details %+v", ack)
+	}
+
+	if overview.ChurnRates.QueueDeclared != 1900 || overview.QueueTotals.MessagesDetails.Rate != -3 {
+		t.Errorf("unexpected churn rates %+v", overview.ChurnRates)
+	}
+}
@@ -0,0 +1,100 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrReadOnly is returned for changing requests (PUT, POST and DELETE, and
+// the aliveness test) of a client returned by ReadOnlyClient.
+var ErrReadOnly = errors.New("client is read-only")
+
+// changes reports whether a request changes the broker. The aliveness test is
+// a GET, but declares its test queue.
+func changes(method, endpoint string) bool {
+	return method != "GET" || strings.HasPrefix(endpoint, "/api/aliveness-test/")
+}
+
+// ReadOnlyClient returns a copy of r which fails every changing request
+// (CreateExchange, DeleteVhost, CreatePermission, ..., and AlivenessTest,
+// which declares a queue) with ErrReadOnly without sending it. Reading calls
+// work as usual.
+//
+// This only guards against mistakes: the copy keeps the exported Username,
+// Password and Url, so code holding it can still create a writable client
+// with Auth. For a guarantee, give such code the credentials of a broker
+// user tagged monitoring without configure and write permissions.
+func (r *Rabbit) ReadOnlyClient() *Rabbit {
+	client := *r
+	client.readOnly = true
+	return &client
+}
+
+// DryRunClient returns a copy of r which records changing requests in the
+// returned log instead of sending them, and reports them as successful.
+// Reading calls, and AlivenessTest, are sent as usual, so calls like
+// ProvisionTenant still see the current state of the broker.
+func (r *Rabbit) DryRunClient() (*Rabbit, *RequestLog) {
+	log := &RequestLog{}
+
+	client := *r
+	client.dryRun = log
+	return &client, log
+}
+
+// RecordedRequest is a request recorded by a dry run client.
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Body   json.RawMessage // nil for requests without body
+}
+
+func (r RecordedRequest) String() string {
+	if len(r.Body) == 0 {
+		return r.Method + " " + r.Path
+	}
+
+	return fmt.Sprintf("%s %s %s", r.Method, r.Path, r.Body)
+}
+
+// RequestLog is the log of a dry run client. It's safe for concurrent use.
+type RequestLog struct {
+	mu       sync.Mutex
+	requests []RecordedRequest
+}
+
+// Requests returns the recorded requests in the order they were made.
+func (l *RequestLog) Requests() []RecordedRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]RecordedRequest{}, l.requests...)
+}
+
+// record adds a request to the log and returns a successful response for it.
+func (l *RequestLog) record(method, endpoint string, body []byte) *http.Response {
+	request := RecordedRequest{Method: method, Path: endpoint}
+	if len(body) != 0 {
+		request.Body = append(json.RawMessage{}, body...)
+	}
+
+	l.mu.Lock()
+	l.requests = append(l.requests, request)
+	l.mu.Unlock()
+
+	status := http.StatusNoContent
+	if method == "POST" {
+		status = http.StatusCreated
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+}
@@ -0,0 +1,87 @@
+package rabbitapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRabbit_ReadOnlyClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" || strings.HasPrefix(req.URL.Path, "/api/aliveness-test/") {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		w.Write([]byte(`{"name":"/"}`))
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	r.Retry = &RetryPolicy{}
+	ro := r.ReadOnlyClient()
+
+	if _, err := ro.GetVhost("/"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ro.CreateExchange("/", "events", ExchangeOptions{Type: "topic"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if err := ro.DeleteVhost("tenant"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+
+	// the aliveness test is a GET, but declares a queue
+	if err := ro.AlivenessTest("/"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+
+	// a dry run client of a read-only client stays read-only
+	dry, log := ro.DryRunClient()
+	if err := dry.CreatePermission("/", "app", ".*", ".*", ".*"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if n := len(log.Requests()); n != 0 {
+		t.Errorf("expected no recorded requests, got %d", n)
+	}
+
+	if r.readOnly {
+		t.Error("original client is read-only")
+	}
+}
+
+func TestRabbit_DryRunClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		w.Write([]byte(`{"name":"/"}`))
+	}))
+	defer ts.Close()
+
+	r, log := Auth("guest", "guest", ts.URL).DryRunClient()
+
+	if _, err := r.GetVhost("/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateExchange("/", "events", ExchangeOptions{Type: "topic"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteVhost("tenant"); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := log.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 recorded requests, got %v", requests)
+	}
+
+	if req := requests[0]; req.Method != "PUT" || req.Path != "/api/exchanges/%2f/events" || len(req.Body) == 0 {
+		t.Errorf("unexpected request %s", req)
+	}
+
+	if got, want := requests[1].String(), "DELETE /api/vhosts/tenant"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package rabbitapi
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy describes how failed idempotent requests are retried. A request
+// is retried if the connection fails or if the api responds with one of the
+// Retryable status codes. The n-th retry waits a random delay of up to
+// BaseDelay*2^(n-1), capped at MaxDelay, so the first retry waits up to
+// BaseDelay. Zero values are replaced with the defaults:
+//
+//	MaxAttempts: 3
+//	BaseDelay:   100ms
+//	MaxDelay:    5s
+//	Retryable:   502, 503, 504
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Retryable   []int
+
+	// Cancel, if set, stops retrying when it's closed, also while waiting
+	// between attempts. The last error is returned then.
+	Cancel <-chan struct{}
+}
+
+// do calls fn until it succeeds, returns a non retryable error or the
+// attempts are exhausted. The last error is returned as is.
+func (p *RetryPolicy) do(fn func() error) error {
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 && !p.wait(p.backoff(i-1)) {
+			return err
+		}
+
+		err = fn()
+		if err == nil || !p.retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// wait sleeps for d and reports whether retrying should go on, which it
+// doesn't if Cancel is closed.
+func (p *RetryPolicy) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-p.Cancel:
+		return false
+	}
+}
+
+// backoff returns a random delay in (0, min(MaxDelay, BaseDelay*2^retry)],
+// where retry is 0 for the first retry.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+
+	max := p.MaxDelay
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+
+	delay := base << uint(attempt)
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// retryable reports whether err is worth another attempt. Api errors are
+// retried only for the configured status codes, every other error is a
+// transport failure (connection refused, reset, etc.) and is retried, except
+// ErrReadOnly.
+func (p *RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, ErrReadOnly) {
+		return false
+	}
+
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return true
+	}
+
+	codes := p.Retryable
+	if codes == nil {
+		codes = []int{502, 503, 504}
+	}
+
+	for _, code := range codes {
+		if apiErr.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isIdempotent reports whether requests with the given method can be safely
+// sent more than once. POST requests (like publishing a message) are never
+// retried.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "PUT", "DELETE":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+package rabbitapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRabbit_Retry(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte(`[{"name":"/","tracing":false}]`))
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	r.Retry = &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	vhosts, err := r.GetVhosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 3 || len(vhosts) != 1 {
+		t.Errorf("expected 3 calls and 1 vhost, got %d calls and %v", calls, vhosts)
+	}
+}
+
+func TestRabbit_RetryExhausted(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	r.Retry = &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+
+	err := r.DeleteVhost("rabbitapi")
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != 503 {
+		t.Fatalf("expected 503 APIError, got %#v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestRabbit_RetryNotRetryable(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.WriteHeader(404)
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	r.Retry = &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+
+	if _, err := r.GetVhost("rabbitapi"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestRabbit_RetryBackoff(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 30 * time.Millisecond}
+
+	limits := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond}
+	for retry, limit := range limits {
+		for i := 0; i < 100; i++ {
+			if d := p.backoff(retry); d <= 0 || d > limit {
+				t.Fatalf("retry %d: delay %s not in (0, %s]", retry, d, limit)
+			}
+		}
+	}
+}
+
+func TestRabbit_RetryCancel(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	cancel := make(chan struct{})
+	close(cancel)
+
+	r := Auth("guest", "guest", ts.URL)
+	r.Retry = &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, Cancel: cancel}
+
+	if _, err := r.GetVhosts(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
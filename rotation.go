@@ -0,0 +1,142 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RotationStep is the outcome of a single step of a password rotation. Err is
+// nil if the step succeeded or was skipped.
+type RotationStep struct {
+	Name string
+	Err  error
+
+	// SkipReason is set if the step could not be taken, e.g. "unverifiable".
+	SkipReason string
+}
+
+// RotationReport describes every step taken by RotateUserPassword.
+type RotationReport struct {
+	User  string
+	Steps []RotationStep
+}
+
+func (r RotationReport) String() string {
+	lines := make([]string, 0, len(r.Steps)+1)
+	lines = append(lines, fmt.Sprintf("password rotation of user '%s':", r.User))
+	for _, step := range r.Steps {
+		switch {
+		case step.SkipReason != "":
+			lines = append(lines, fmt.Sprintf("  %s: skipped (%s)", step.Name, step.SkipReason))
+		case step.Err != nil:
+			lines = append(lines, fmt.Sprintf("  %s: %s", step.Name, step.Err))
+		default:
+			lines = append(lines, fmt.Sprintf("  %s: ok", step.Name))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (r *RotationReport) add(name string, err error) error {
+	r.Steps = append(r.Steps, RotationStep{Name: name, Err: err})
+	return err
+}
+
+// RotateUserPassword changes the password of the user, keeping its tags and
+// permissions. The new password is verified by calling Whoami with it. Users
+// without tags can't use the management api, so for them Whoami always fails
+// with 401 and the step is reported as skipped ("unverifiable") rather than
+// failed. If closeConnections is true, the existing connections of the user
+// (which were opened with the old password) are closed afterwards. The report
+// contains the outcome of every step taken; the rotation stops at the first
+// failed step, whose error is returned.
+func (r *Rabbit) RotateUserPassword(name, newPassword string, closeConnections bool) (RotationReport, error) {
+	report := RotationReport{User: name}
+
+	user, err := r.GetUser(name)
+	if report.add("read user", err) != nil {
+		return report, err
+	}
+
+	permissions, err := r.GetUserPermissions(name)
+	if report.add("read permissions", err) != nil {
+		return report, err
+	}
+
+	err = r.CreateUser(name, newPassword, user.Tags)
+	if report.add("update password", err) != nil {
+		return report, err
+	}
+
+	err = r.restorePermissions(name, permissions)
+	if report.add("verify permissions", err) != nil {
+		return report, err
+	}
+
+	client := *r
+	client.Username = name
+	client.Password = newPassword
+	current, err := client.Whoami()
+	if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == 401 && len(user.Tags) == 0 {
+		report.Steps = append(report.Steps, RotationStep{
+			Name:       "verify credentials",
+			SkipReason: "unverifiable, users without tags can't access the management api",
+		})
+	} else {
+		if err == nil && current.Name != name {
+			err = fmt.Errorf("authenticated as '%s'", current.Name)
+		}
+		if report.add("verify credentials", err) != nil {
+			return report, err
+		}
+	}
+
+	if !closeConnections {
+		return report, nil
+	}
+
+	connections, err := r.GetConnections()
+	if report.add("list connections", err) != nil {
+		return report, err
+	}
+
+	for _, c := range connections {
+		if c.User != name {
+			continue
+		}
+
+		err := r.CloseConnection(c.Name)
+		if report.add("close connection "+c.Name, err) != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// restorePermissions makes sure the user still has the given permissions,
+// creating the ones which are missing or changed.
+func (r *Rabbit) restorePermissions(name string, permissions []Permission) error {
+	current, err := r.GetUserPermissions(name)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]Permission)
+	for _, p := range current {
+		existing[p.Vhost] = p
+	}
+
+	for _, p := range permissions {
+		if existing[p.Vhost] == p {
+			continue
+		}
+
+		if err := r.CreatePermission(p.Vhost, name, p.Configure, p.Write, p.Read); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
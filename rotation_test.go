@@ -0,0 +1,91 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRabbit_RotateUserPassword(t *testing.T) {
+	var updated map[string]interface{}
+	var closed []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method + " " + req.URL.Path {
+		case "GET /api/users/app":
+			w.Write([]byte(`{"name":"app","password_hash":"old","tags":"monitoring"}`))
+		case "GET /api/users/app/permissions":
+			w.Write([]byte(`[{"user":"app","vhost":"/","configure":".*","write":".*","read":".*"}]`))
+		case "PUT /api/users/app":
+			body, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(body, &updated)
+			w.WriteHeader(204)
+		case "GET /api/whoami":
+			username, password, _ := req.BasicAuth()
+			if username != "app" || password != "new" {
+				w.WriteHeader(401)
+				return
+			}
+			w.Write([]byte(`{"name":"app","tags":"monitoring"}`))
+		case "GET /api/connections":
+			w.Write([]byte(`[{"name":"10.0.0.1:4000 -> 10.0.0.2:5672","user":"app"},{"name":"other","user":"guest"}]`))
+		case "DELETE /api/connections/10.0.0.1:4000 -> 10.0.0.2:5672":
+			closed = append(closed, req.URL.Path)
+			w.WriteHeader(204)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(500)
+		}
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	report, err := r.RotateUserPassword("app", "new", true)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, report)
+	}
+
+	if updated["password"] != "new" || updated["tags"] != "monitoring" {
+		t.Errorf("unexpected user update %v", updated)
+	}
+
+	if len(closed) != 1 {
+		t.Errorf("expected 1 closed connection, got %v", closed)
+	}
+
+	if len(report.Steps) != 7 {
+		t.Errorf("expected 7 steps, got:\n%s", report)
+	}
+}
+
+func TestRabbit_RotateUserPasswordWithoutTags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method + " " + req.URL.Path {
+		case "GET /api/users/app":
+			w.Write([]byte(`{"name":"app","password_hash":"old","tags":""}`))
+		case "GET /api/users/app/permissions":
+			w.Write([]byte(`[]`))
+		case "PUT /api/users/app":
+			w.WriteHeader(204)
+		case "GET /api/whoami":
+			w.WriteHeader(401)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(500)
+		}
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	report, err := r.RotateUserPassword("app", "new", false)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, report)
+	}
+
+	if !strings.Contains(report.String(), "verify credentials: skipped (unverifiable") {
+		t.Errorf("expected unverifiable credentials, got:\n%s", report)
+	}
+}
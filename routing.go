@@ -0,0 +1,312 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Routing is the result of a routing simulation.
+type Routing struct {
+	// Queues are the names of the queues the message would be delivered to.
+	// It's empty if the message is unroutable.
+	Queues []string
+
+	// Trace describes every step taken while routing the message.
+	Trace []string
+}
+
+// routingSource provides the exchanges and bindings used for routing.
+type routingSource interface {
+	exchange(vhost, name string) (Exchange, error)
+	bindings(vhost, source string) ([]Binding, error)
+}
+
+// Route simulates publishing a message with the given routing key and headers
+// to the exchange, using the live bindings of the broker (via GetExchange and
+// GetExchangeSource). Nothing is published. Direct, fanout, topic and headers
+// exchanges are supported, exchange to exchange bindings are followed and
+// alternate exchanges (the "alternate-exchange" argument) are used for
+// messages which match none of the bindings of an exchange. An empty exchange
+// name is the default exchange.
+func (r *Rabbit) Route(vhost, exchange, routingKey string, headers map[string]interface{}) (Routing, error) {
+	return route(liveRoutes{r}, vhost, exchange, routingKey, headers)
+}
+
+// Route is like Rabbit.Route, but works offline on the exchanges, queues and
+// bindings of the topology.
+func (t Topology) Route(vhost, exchange, routingKey string, headers map[string]interface{}) (Routing, error) {
+	return route(topologyRoutes{t}, vhost, exchange, routingKey, headers)
+}
+
+type liveRoutes struct {
+	r *Rabbit
+}
+
+func (l liveRoutes) exchange(vhost, name string) (Exchange, error) {
+	if name == "" {
+		return Exchange{Name: "", Type: "direct", Vhost: vhost}, nil
+	}
+
+	return l.r.GetExchange(vhost, name)
+}
+
+func (l liveRoutes) bindings(vhost, source string) ([]Binding, error) {
+	if source == "" {
+		source = "amq.default"
+	}
+
+	return l.r.GetExchangeSource(vhost, source)
+}
+
+type topologyRoutes struct {
+	t Topology
+}
+
+func (o topologyRoutes) exchange(vhost, name string) (Exchange, error) {
+	if name == "" {
+		return Exchange{Name: "", Type: "direct", Vhost: vhost}, nil
+	}
+
+	for _, e := range o.t.Exchanges {
+		if e.Vhost == vhost && e.Name == name {
+			return e, nil
+		}
+	}
+
+	return Exchange{}, fmt.Errorf("exchange '%s' not found in vhost '%s'", name, vhost)
+}
+
+func (o topologyRoutes) bindings(vhost, source string) ([]Binding, error) {
+	bindings := make([]Binding, 0)
+
+	// the default exchange is implicitly bound to every queue
+	if source == "" {
+		for _, q := range o.t.Queues {
+			if q.Vhost == vhost {
+				bindings = append(bindings, Binding{
+					Vhost:           vhost,
+					Destination:     q.Name,
+					DestinationType: DestinationQueue,
+					RoutingKey:      q.Name,
+				})
+			}
+		}
+		return bindings, nil
+	}
+
+	for _, b := range o.t.Bindings {
+		if b.Vhost == vhost && b.Source == source {
+			bindings = append(bindings, b)
+		}
+	}
+
+	return bindings, nil
+}
+
+func route(src routingSource, vhost, exchange, routingKey string, headers map[string]interface{}) (Routing, error) {
+	e, err := src.exchange(vhost, exchange)
+	if err != nil {
+		return Routing{}, err
+	}
+
+	if e.Internal {
+		return Routing{}, fmt.Errorf("exchange '%s' is internal, messages can't be published to it", exchange)
+	}
+
+	s := &simulation{
+		src:        src,
+		vhost:      vhost,
+		routingKey: routingKey,
+		headers:    headers,
+		visited:    make(map[string]bool),
+		queues:     make(map[string]bool),
+	}
+
+	if err := s.exchange(e); err != nil {
+		return Routing{}, err
+	}
+
+	queues := make([]string, 0, len(s.queues))
+	for q := range s.queues {
+		queues = append(queues, q)
+	}
+	sort.Strings(queues)
+
+	if len(queues) == 0 {
+		s.tracef("message is unroutable")
+	}
+
+	return Routing{Queues: queues, Trace: s.trace}, nil
+}
+
+type simulation struct {
+	src        routingSource
+	vhost      string
+	routingKey string
+	headers    map[string]interface{}
+
+	visited map[string]bool
+	queues  map[string]bool
+	trace   []string
+}
+
+func (s *simulation) tracef(format string, args ...interface{}) {
+	s.trace = append(s.trace, fmt.Sprintf(format, args...))
+}
+
+// exchange routes the message through e. The alternate exchange of e is
+// only used if none of its bindings matched, even if the matching bindings
+// lead to exchanges which route the message nowhere.
+func (s *simulation) exchange(e Exchange) error {
+	if s.visited[e.Name] {
+		return nil // an exchange routes a message only once
+	}
+	s.visited[e.Name] = true
+
+	kind := e.Type
+	if kind == "x-delayed-message" {
+		kind, _ = e.Arguments["x-delayed-type"].(string)
+	}
+
+	bindings, err := s.src.bindings(s.vhost, e.Name)
+	if err != nil {
+		return err
+	}
+
+	matched := false
+	for _, b := range bindings {
+		ok, err := matchBinding(kind, b, s.routingKey, s.headers)
+		if err != nil {
+			return fmt.Errorf("exchange '%s': %s", e.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		matched = true
+
+		if b.DestinationType == DestinationExchange {
+			s.tracef("exchange '%s' (%s) -> exchange '%s' via '%s'", e.Name, e.Type, b.Destination, b.RoutingKey)
+
+			dest, err := s.src.exchange(s.vhost, b.Destination)
+			if err != nil {
+				return err
+			}
+
+			if err := s.exchange(dest); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.tracef("exchange '%s' (%s) -> queue '%s' via '%s'", e.Name, e.Type, b.Destination, b.RoutingKey)
+		s.queues[b.Destination] = true
+	}
+
+	if matched {
+		return nil
+	}
+
+	ae, _ := e.Arguments["alternate-exchange"].(string)
+	if ae == "" {
+		s.tracef("exchange '%s' (%s) has no matching binding", e.Name, e.Type)
+		return nil
+	}
+
+	s.tracef("exchange '%s' (%s) has no matching binding, using alternate exchange '%s'", e.Name, e.Type, ae)
+	alternate, err := s.src.exchange(s.vhost, ae)
+	if err != nil {
+		return err
+	}
+
+	return s.exchange(alternate)
+}
+
+// matchBinding reports whether a message matches the binding of an exchange
+// of the given type.
+func matchBinding(kind string, b Binding, routingKey string, headers map[string]interface{}) (bool, error) {
+	switch kind {
+	case "direct":
+		return b.RoutingKey == routingKey, nil
+	case "fanout":
+		return true, nil
+	case "topic":
+		return matchTopic(strings.Split(b.RoutingKey, "."), strings.Split(routingKey, ".")), nil
+	case "headers":
+		return matchHeaders(b.Arguments, headers), nil
+	default:
+		return false, fmt.Errorf("routing of exchange type '%s' can't be simulated", kind)
+	}
+}
+
+// matchTopic matches the words of a routing key against the words of a
+// binding pattern, where "*" matches exactly one word and "#" zero or more
+// words.
+func matchTopic(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if matchTopic(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && matchTopic(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && pattern[0] == words[0] && matchTopic(pattern[1:], words[1:])
+	}
+}
+
+// matchHeaders matches message headers against the arguments of a headers
+// exchange binding. "x-match" is either "all" (the default) or "any"; the
+// "-with-x" variants also compare arguments starting with "x-". An argument
+// without a value matches if the header is present.
+func matchHeaders(args, headers map[string]interface{}) bool {
+	mode, _ := args["x-match"].(string)
+	if mode == "" {
+		mode = "all"
+	}
+
+	withX := strings.HasSuffix(mode, "-with-x")
+	matchAny := strings.HasPrefix(mode, "any")
+
+	for key, want := range args {
+		if key == "x-match" || (!withX && strings.HasPrefix(key, "x-")) {
+			continue
+		}
+
+		got, ok := headers[key]
+		ok = ok && (want == nil || jsonEqual(want, got))
+
+		if matchAny && ok {
+			return true
+		}
+		if !matchAny && !ok {
+			return false
+		}
+	}
+
+	return !matchAny
+}
+
+// jsonEqual compares two values by their json encoding, so that numbers of
+// different types compare equal.
+func jsonEqual(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return string(x) == string(y)
+}
@@ -0,0 +1,103 @@
+package rabbitapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+var routingTopology = Topology{
+	Exchanges: []Exchange{
+		{Vhost: "/", Name: "events", Type: "topic", Arguments: map[string]interface{}{"alternate-exchange": "unrouted"}},
+		{Vhost: "/", Name: "unrouted", Type: "fanout"},
+		{Vhost: "/", Name: "audit", Type: "headers"},
+		{Vhost: "/", Name: "direct", Type: "direct"},
+		{Vhost: "/", Name: "fallback", Type: "direct", Arguments: map[string]interface{}{"alternate-exchange": "unrouted"}},
+		{Vhost: "/", Name: "loop", Type: "fanout", Arguments: map[string]interface{}{"alternate-exchange": "unrouted"}},
+	},
+	Queues: []Queue{
+		{Vhost: "/", Name: "orders"},
+		{Vhost: "/", Name: "all"},
+		{Vhost: "/", Name: "lost"},
+		{Vhost: "/", Name: "eu-audit"},
+	},
+	Bindings: []Binding{
+		{Vhost: "/", Source: "events", Destination: "orders", DestinationType: DestinationQueue, RoutingKey: "order.*"},
+		{Vhost: "/", Source: "events", Destination: "all", DestinationType: DestinationQueue, RoutingKey: "#.created"},
+		{Vhost: "/", Source: "events", Destination: "audit", DestinationType: DestinationExchange, RoutingKey: "#"},
+		{Vhost: "/", Source: "unrouted", Destination: "lost", DestinationType: DestinationQueue},
+		{Vhost: "/", Source: "audit", Destination: "eu-audit", DestinationType: DestinationQueue,
+			Arguments: map[string]interface{}{"x-match": "all", "region": "eu", "level": float64(2)}},
+		{Vhost: "/", Source: "direct", Destination: "orders", DestinationType: DestinationQueue, RoutingKey: "orders"},
+		{Vhost: "/", Source: "fallback", Destination: "orders", DestinationType: DestinationQueue, RoutingKey: "orders"},
+		{Vhost: "/", Source: "loop", Destination: "loop", DestinationType: DestinationExchange},
+	},
+}
+
+func TestRabbit_TopologyRoute(t *testing.T) {
+	tests := []struct {
+		exchange   string
+		routingKey string
+		headers    map[string]interface{}
+		queues     []string
+	}{
+		{"events", "order.created", nil, []string{"all", "orders"}},
+		{"events", "user.profile.created", nil, []string{"all"}},
+		{"events", "order.eu.shipped", map[string]interface{}{"region": "eu", "level": 2}, []string{"eu-audit"}},
+		// the binding to audit matched, so the alternate exchange is not
+		// used although audit routes the message nowhere
+		{"events", "order.eu.shipped", map[string]interface{}{"region": "us", "level": 2}, []string{}},
+		{"fallback", "orders", nil, []string{"orders"}},
+		{"fallback", "other", nil, []string{"lost"}},
+		// the binding to the already visited loop exchange matched
+		{"loop", "", nil, []string{}},
+		{"direct", "orders", nil, []string{"orders"}},
+		{"direct", "order", nil, []string{}},
+		{"", "lost", nil, []string{"lost"}},
+	}
+
+	for _, test := range tests {
+		routing, err := routingTopology.Route("/", test.exchange, test.routingKey, test.headers)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(routing.Queues, test.queues) {
+			t.Errorf("%s/%s: expected %v, got %v\n%v", test.exchange, test.routingKey, test.queues, routing.Queues, routing.Trace)
+		}
+	}
+}
+
+func TestRabbit_MatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		match        bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.*", "a.b", true},
+		{"a.*", "a.b.c", false},
+		{"a.#", "a", true},
+		{"#", "", true},
+		{"#.c", "a.b.c", true},
+		{"a.#.c", "a.c", true},
+		{"*.b", "b", false},
+	}
+
+	for _, test := range tests {
+		r, _ := matchBinding("topic", Binding{RoutingKey: test.pattern}, test.key, nil)
+		if r != test.match {
+			t.Errorf("%q with %q: expected %v", test.pattern, test.key, test.match)
+		}
+	}
+}
+
+func TestRabbit_MatchHeaders(t *testing.T) {
+	args := map[string]interface{}{"x-match": "any", "a": "1", "b": "2"}
+	if !matchHeaders(args, map[string]interface{}{"b": "2"}) {
+		t.Error("expected any to match a single header")
+	}
+
+	args["x-match"] = "all"
+	if matchHeaders(args, map[string]interface{}{"b": "2"}) {
+		t.Error("expected all to require every header")
+	}
+}
@@ -0,0 +1,366 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SpecVersion is the topology spec version understood by LoadTopology.
+const SpecVersion = 1
+
+// topologySpec is the on disk format of a topology. Fields use the same names
+// as the management api, e.g.:
+//
+//	version: 1
+//	vhosts:
+//	  - name: tenant
+//	exchanges:
+//	  - vhost: tenant
+//	    name: events
+//	    type: topic
+//	    durable: true
+//	queues:
+//	  - vhost: tenant
+//	    name: jobs
+//	    durable: true
+//	bindings:
+//	  - vhost: tenant
+//	    source: events
+//	    destination: jobs
+//	    destination_type: queue
+//	    routing_key: "jobs.#"
+type topologySpec struct {
+	Version     int          `json:"version"`
+	Exported    string       `json:"rabbit_version"` // set in exported definitions
+	Vhosts      []Vhost      `json:"vhosts"`
+	Users       []User       `json:"users"`
+	Permissions []Permission `json:"permissions"`
+	Exchanges   []Exchange   `json:"exchanges"`
+	Queues      []Queue      `json:"queues"`
+	Bindings    []Binding    `json:"bindings"`
+	Policies    []Policy     `json:"policies"`
+}
+
+// SpecError is a validation error of a topology spec.
+type SpecError struct {
+	Line    int
+	Message string
+}
+
+func (e SpecError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// SpecErrors is returned by LoadTopology if the spec is not valid. It
+// contains every problem found, not just the first one.
+type SpecErrors []SpecError
+
+func (e SpecErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// LoadTopologyFile reads the YAML or JSON topology spec at path. See
+// LoadTopology.
+func LoadTopologyFile(path string) (Topology, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Topology{}, err
+	}
+
+	return LoadTopology(data)
+}
+
+// LoadTopology parses and validates a YAML or JSON topology spec. The
+// returned Topology can be passed to Reconcile, or its items to calls like
+// CreateExchange. Objects without a vhost belong to the default vhost "/",
+// which doesn't need to be declared. Definitions exported from the
+// management api (with a rabbit_version key instead of version) are accepted
+// as well. Validation errors, including unknown keys (e.g. a misspelled
+// "exchange:" list or "duarble" field), are returned as SpecErrors with the
+// line of the offending item or key.
+func LoadTopology(data []byte) (Topology, error) {
+	// JSON is valid YAML, so both formats go through the YAML parser,
+	// which gives us line numbers.
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(data, root); err != nil {
+		return Topology{}, err
+	}
+
+	var raw interface{}
+	if err := root.Decode(&raw); err != nil {
+		return Topology{}, err
+	}
+
+	// decode with the json tags of the api types
+	buf, err := json.Marshal(raw)
+	if err != nil {
+		return Topology{}, err
+	}
+
+	spec := topologySpec{}
+	if err := json.Unmarshal(buf, &spec); err != nil {
+		return Topology{}, err
+	}
+
+	errs := unknownSpecKeys(root)
+	errs = append(errs, validateSpec(&spec, specLines(root))...)
+	if len(errs) != 0 {
+		return Topology{}, errs
+	}
+
+	return spec.topology(), nil
+}
+
+func (spec topologySpec) topology() Topology {
+	return Topology{
+		Vhosts:      spec.Vhosts,
+		Users:       spec.Users,
+		Permissions: spec.Permissions,
+		Exchanges:   spec.Exchanges,
+		Queues:      spec.Queues,
+		Bindings:    spec.Bindings,
+		Policies:    spec.Policies,
+	}
+}
+
+// specSections are the item types of the top level lists of a spec, keyed
+// by the list name.
+var specSections = map[string]reflect.Type{
+	"vhosts":      reflect.TypeOf(Vhost{}),
+	"users":       reflect.TypeOf(User{}),
+	"permissions": reflect.TypeOf(Permission{}),
+	"exchanges":   reflect.TypeOf(Exchange{}),
+	"queues":      reflect.TypeOf(Queue{}),
+	"bindings":    reflect.TypeOf(Binding{}),
+	"policies":    reflect.TypeOf(Policy{}),
+}
+
+// exportedKeys are the keys of exported definitions which are not part of a
+// topology, keyed by the list name ("" for top level keys). They are
+// accepted and ignored.
+var exportedKeys = map[string][]string{
+	"":       {"rabbitmq_version", "product_name", "product_version", "topic_permissions", "parameters", "global_parameters"},
+	"vhosts": {"limits", "metadata", "description", "tags", "default_queue_type"},
+	"users":  {"limits"},
+	"queues": {"type"},
+}
+
+// unknownSpecKeys reports the top level keys and the fields of list items
+// which are not part of a spec.
+func unknownSpecKeys(root *yaml.Node) SpecErrors {
+	errs := make(SpecErrors, 0)
+
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+
+	if doc.Kind != yaml.MappingNode {
+		return errs
+	}
+
+	known := jsonKeys(reflect.TypeOf(topologySpec{}), exportedKeys[""])
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if !known[key.Value] {
+			errs = append(errs, SpecError{Line: key.Line, Message: fmt.Sprintf("unknown key %q", key.Value)})
+			continue
+		}
+
+		typ, ok := specSections[key.Value]
+		if !ok || value.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		fields := jsonKeys(typ, exportedKeys[key.Value])
+		for _, item := range value.Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+
+			for j := 0; j+1 < len(item.Content); j += 2 {
+				if field := item.Content[j]; !fields[field.Value] {
+					msg := fmt.Sprintf("unknown field %q in %s", field.Value, key.Value)
+					errs = append(errs, SpecError{Line: field.Line, Message: msg})
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// jsonKeys returns the json names of the fields of typ, and extra.
+func jsonKeys(typ reflect.Type, extra []string) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+
+	for _, key := range extra {
+		keys[key] = true
+	}
+
+	return keys
+}
+
+// specLines returns the line of each item of each top level list, keyed by
+// the list name, and the line of the version key.
+func specLines(root *yaml.Node) map[string][]int {
+	lines := make(map[string][]int)
+
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+
+	if doc.Kind != yaml.MappingNode {
+		return lines
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if key.Value == "version" {
+			lines["version"] = []int{key.Line}
+			continue
+		}
+
+		for _, item := range value.Content {
+			lines[key.Value] = append(lines[key.Value], item.Line)
+		}
+	}
+
+	return lines
+}
+
+// validateSpec fills in defaults and checks the spec for unsupported
+// versions, unknown exchange types, missing vhosts and dangling bindings.
+func validateSpec(spec *topologySpec, lines map[string][]int) SpecErrors {
+	errs := make(SpecErrors, 0)
+	add := func(section string, i int, format string, args ...interface{}) {
+		line := 0
+		if i < len(lines[section]) {
+			line = lines[section][i]
+		}
+		errs = append(errs, SpecError{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// definitions exported by the broker have no version of their own
+	if spec.Version != SpecVersion && !(spec.Version == 0 && spec.Exported != "") {
+		add("version", 0, "unsupported spec version %d, expected %d", spec.Version, SpecVersion)
+	}
+
+	vhosts := map[string]bool{"/": true}
+	for i, v := range spec.Vhosts {
+		if v.Name == "" {
+			add("vhosts", i, "vhost name is empty")
+		}
+		vhosts[v.Name] = true
+	}
+
+	checkVhost := func(section string, i int, vhost *string) {
+		if *vhost == "" {
+			*vhost = "/"
+		}
+		if !vhosts[*vhost] {
+			add(section, i, "vhost %q is not declared", *vhost)
+		}
+	}
+
+	for i, u := range spec.Users {
+		if u.Name == "" {
+			add("users", i, "user name is empty")
+		}
+	}
+
+	for i := range spec.Permissions {
+		p := &spec.Permissions[i]
+		checkVhost("permissions", i, &p.Vhost)
+		if p.User == "" {
+			add("permissions", i, "permission user is empty")
+		}
+	}
+
+	exchanges := make(map[string]bool)
+	for i := range spec.Exchanges {
+		e := &spec.Exchanges[i]
+		checkVhost("exchanges", i, &e.Vhost)
+		if e.Name == "" {
+			add("exchanges", i, "exchange name is empty")
+		}
+		if err := e.Options().Validate(); err != nil {
+			add("exchanges", i, "exchange %q: %s", e.Name, err)
+		}
+		exchanges[e.Vhost+"\x00"+e.Name] = true
+	}
+
+	queues := make(map[string]bool)
+	for i := range spec.Queues {
+		q := &spec.Queues[i]
+		checkVhost("queues", i, &q.Vhost)
+		if q.Name == "" {
+			add("queues", i, "queue name is empty")
+		}
+		queues[q.Vhost+"\x00"+q.Name] = true
+	}
+
+	for i := range spec.Bindings {
+		b := &spec.Bindings[i]
+		checkVhost("bindings", i, &b.Vhost)
+		if b.DestinationType == "" {
+			b.DestinationType = DestinationQueue
+		}
+
+		if !exchanges[b.Vhost+"\x00"+b.Source] && !isDefaultExchange(b.Source) {
+			add("bindings", i, "binding source exchange %q is not declared", b.Source)
+		}
+
+		switch b.DestinationType {
+		case DestinationQueue:
+			if !queues[b.Vhost+"\x00"+b.Destination] {
+				add("bindings", i, "binding destination queue %q is not declared", b.Destination)
+			}
+		case DestinationExchange:
+			if !exchanges[b.Vhost+"\x00"+b.Destination] && !isDefaultExchange(b.Destination) {
+				add("bindings", i, "binding destination exchange %q is not declared", b.Destination)
+			}
+		default:
+			add("bindings", i, "unknown binding destination type %q", b.DestinationType)
+		}
+	}
+
+	for i := range spec.Policies {
+		p := &spec.Policies[i]
+		checkVhost("policies", i, &p.Vhost)
+		if p.Name == "" {
+			add("policies", i, "policy name is empty")
+		}
+
+		switch p.ApplyTo {
+		case "":
+			p.ApplyTo = "all"
+		case "all", "queues", "exchanges":
+		default:
+			add("policies", i, "policy %q has unknown apply-to %q", p.Name, p.ApplyTo)
+		}
+	}
+
+	return errs
+}
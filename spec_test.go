@@ -0,0 +1,135 @@
+package rabbitapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRabbit_LoadTopology(t *testing.T) {
+	spec := `
+version: 1
+vhosts:
+  - name: tenant
+users:
+  - name: app
+    password: secret
+    tags: monitoring
+permissions:
+  - vhost: tenant
+    user: app
+    configure: ".*"
+    write: ".*"
+    read: ".*"
+exchanges:
+  - vhost: tenant
+    name: events
+    type: topic
+    auto_delete: true
+queues:
+  - vhost: tenant
+    name: jobs
+    arguments:
+      x-max-length: 10
+bindings:
+  - vhost: tenant
+    source: events
+    destination: jobs
+    routing_key: "jobs.#"
+policies:
+  - vhost: tenant
+    name: ttl
+    pattern: "^jobs$"
+    apply-to: queues
+    definition:
+      message-ttl: 60000
+`
+
+	topology, err := LoadTopology([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e := topology.Exchanges[0]; e.Name != "events" || e.Type != "topic" || !e.AutoDelete {
+		t.Errorf("unexpected exchange %+v", e)
+	}
+
+	if b := topology.Bindings[0]; b.DestinationType != DestinationQueue || b.RoutingKey != "jobs.#" {
+		t.Errorf("unexpected binding %+v", b)
+	}
+
+	if p := topology.Policies[0]; p.ApplyTo != "queues" || p.Definition["message-ttl"] != float64(60000) {
+		t.Errorf("unexpected policy %+v", p)
+	}
+
+	if u := topology.Users[0]; u.Name != "app" || u.Password != "secret" {
+		t.Errorf("unexpected user %+v", u)
+	}
+}
+
+func TestRabbit_LoadTopologyJSON(t *testing.T) {
+	spec := `{"version": 1, "queues": [{"name": "jobs", "durable": true}]}`
+
+	topology, err := LoadTopology([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q := topology.Queues[0]; q.Vhost != "/" || !q.Durable {
+		t.Errorf("unexpected queue %+v", q)
+	}
+}
+
+func TestRabbit_LoadTopologyInvalid(t *testing.T) {
+	spec := `version: 1
+exchanges:
+  - vhost: missing
+    name: events
+    type: tpoic
+bindings:
+  - source: events
+    destination: jobs
+`
+
+	_, err := LoadTopology([]byte(spec))
+	errs, ok := err.(SpecErrors)
+	if !ok {
+		t.Fatalf("expected SpecErrors, got %v", err)
+	}
+
+	expected := []string{
+		`line 3: vhost "missing" is not declared`,
+		`line 3: exchange "events": unknown exchange type 'tpoic'`,
+		`line 7: binding source exchange "events" is not declared`,
+		`line 7: binding destination queue "jobs" is not declared`,
+	}
+
+	if errs.Error() != strings.Join(expected, "\n") {
+		t.Errorf("unexpected errors:\n%s", errs)
+	}
+}
+
+func TestRabbit_LoadTopologyUnknownKeys(t *testing.T) {
+	spec := `version: 1
+exchange:
+  - name: events
+    type: topic
+queues:
+  - name: jobs
+    duarble: true
+`
+
+	_, err := LoadTopology([]byte(spec))
+	errs, ok := err.(SpecErrors)
+	if !ok {
+		t.Fatalf("expected SpecErrors, got %v", err)
+	}
+
+	expected := []string{
+		`line 2: unknown key "exchange"`,
+		`line 7: unknown field "duarble" in queues`,
+	}
+
+	if errs.Error() != strings.Join(expected, "\n") {
+		t.Errorf("unexpected errors:\n%s", errs)
+	}
+}
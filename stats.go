@@ -0,0 +1,153 @@
+package rabbitapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// StatsOptions requests samples of statistics from the getters which accept
+// them. Age is how far back to sample and Incr the interval between samples,
+// e.g. an age of 10 minutes with an increment of 10 seconds returns 60
+// samples. Samples are returned in the Samples field of the RateDetails of
+// the object. Zero durations are not sent, others are rounded up to whole
+// seconds, the unit of the api.
+type StatsOptions struct {
+	// queue lengths (messages, messages_ready, messages_unacknowledged)
+	LengthsAge  time.Duration
+	LengthsIncr time.Duration
+
+	// message rates (message_stats)
+	MsgRatesAge  time.Duration
+	MsgRatesIncr time.Duration
+
+	// data rates (recv_oct, send_oct)
+	DataRatesAge  time.Duration
+	DataRatesIncr time.Duration
+}
+
+// SampleLast returns options which sample lengths, message and data rates for
+// the last age with the given increment.
+func SampleLast(age, incr time.Duration) StatsOptions {
+	return StatsOptions{
+		LengthsAge:    age,
+		LengthsIncr:   incr,
+		MsgRatesAge:   age,
+		MsgRatesIncr:  incr,
+		DataRatesAge:  age,
+		DataRatesIncr: incr,
+	}
+}
+
+// statsQuery returns the query string for the optional stats options, which
+// is empty if no options are given.
+func statsQuery(opts []StatsOptions) string {
+	v := url.Values{}
+	for _, o := range opts {
+		params := []struct {
+			name  string
+			value time.Duration
+		}{
+			{"lengths_age", o.LengthsAge},
+			{"lengths_incr", o.LengthsIncr},
+			{"msg_rates_age", o.MsgRatesAge},
+			{"msg_rates_incr", o.MsgRatesIncr},
+			{"data_rates_age", o.DataRatesAge},
+			{"data_rates_incr", o.DataRatesIncr},
+		}
+
+		for _, p := range params {
+			if p.value > 0 {
+				seconds := (p.value + time.Second - 1) / time.Second
+				v.Set(p.name, strconv.FormatInt(int64(seconds), 10))
+			}
+		}
+	}
+
+	if len(v) == 0 {
+		return ""
+	}
+
+	return "?" + v.Encode()
+}
+
+// Sample is a single data point of a statistic.
+type Sample struct {
+	Sample    float64 `json:"sample"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// RateDetails describes how a counter changes over time. Samples, Avg and
+// AvgRate are only returned if samples are requested. Interval and LastEvent
+// are only returned by old brokers.
+type RateDetails struct {
+	Rate      float64  `json:"rate"`
+	Samples   []Sample `json:"samples"`
+	Avg       float64  `json:"avg"`
+	AvgRate   float64  `json:"avg_rate"`
+	Interval  int      `json:"interval"`
+	LastEvent int64    `json:"last_event"`
+}
+
+// MessageStats are the message counters of an object, together with their
+// rates.
+type MessageStats struct {
+	Ack                     int64       `json:"ack"`
+	AckDetails              RateDetails `json:"ack_details"`
+	Confirm                 int64       `json:"confirm"`
+	ConfirmDetails          RateDetails `json:"confirm_details"`
+	Deliver                 int64       `json:"deliver"`
+	DeliverDetails          RateDetails `json:"deliver_details"`
+	DeliverGet              int64       `json:"deliver_get"`
+	DeliverGetDetails       RateDetails `json:"deliver_get_details"`
+	DeliverNoAck            int64       `json:"deliver_no_ack"`
+	DeliverNoAckDetails     RateDetails `json:"deliver_no_ack_details"`
+	Get                     int64       `json:"get"`
+	GetDetails              RateDetails `json:"get_details"`
+	GetNoAck                int64       `json:"get_no_ack"`
+	GetNoAckDetails         RateDetails `json:"get_no_ack_details"`
+	Publish                 int64       `json:"publish"`
+	PublishDetails          RateDetails `json:"publish_details"`
+	PublishIn               int64       `json:"publish_in"`
+	PublishInDetails        RateDetails `json:"publish_in_details"`
+	PublishOut              int64       `json:"publish_out"`
+	PublishOutDetails       RateDetails `json:"publish_out_details"`
+	Redeliver               int64       `json:"redeliver"`
+	RedeliverDetails        RateDetails `json:"redeliver_details"`
+	ReturnUnroutable        int64       `json:"return_unroutable"`
+	ReturnUnroutableDetails RateDetails `json:"return_unroutable_details"`
+	DropUnroutable          int64       `json:"drop_unroutable"`
+	DropUnroutableDetails   RateDetails `json:"drop_unroutable_details"`
+}
+
+func (m *MessageStats) UnmarshalJSON(data []byte) error {
+	// old brokers return an empty array if there are no stats yet
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		*m = MessageStats{}
+		return nil
+	}
+
+	type plain MessageStats
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// ChurnRates are the counters of created and closed connections and channels
+// and created, declared and deleted queues.
+type ChurnRates struct {
+	ChannelClosed            int64       `json:"channel_closed"`
+	ChannelClosedDetails     RateDetails `json:"channel_closed_details"`
+	ChannelCreated           int64       `json:"channel_created"`
+	ChannelCreatedDetails    RateDetails `json:"channel_created_details"`
+	ConnectionClosed         int64       `json:"connection_closed"`
+	ConnectionClosedDetails  RateDetails `json:"connection_closed_details"`
+	ConnectionCreated        int64       `json:"connection_created"`
+	ConnectionCreatedDetails RateDetails `json:"connection_created_details"`
+	QueueCreated             int64       `json:"queue_created"`
+	QueueCreatedDetails      RateDetails `json:"queue_created_details"`
+	QueueDeclared            int64       `json:"queue_declared"`
+	QueueDeclaredDetails     RateDetails `json:"queue_declared_details"`
+	QueueDeleted             int64       `json:"queue_deleted"`
+	QueueDeletedDetails      RateDetails `json:"queue_deleted_details"`
+}
@@ -0,0 +1,45 @@
+package rabbitapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRabbit_StatsOptions(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query = req.URL.RawQuery
+		w.Write([]byte(`{"name":"jobs","messages":3,
+			"messages_details":{"rate":0.5,"samples":[{"sample":3,"timestamp":1700000010000},{"sample":1,"timestamp":1700000000000}]},
+			"message_stats":{"publish":10,"publish_details":{"rate":1.0,"samples":[{"sample":10,"timestamp":1700000010000}]}}}`))
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	queue, err := r.GetQueue("/", "jobs", SampleLast(10*time.Minute, 10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "data_rates_age=600&data_rates_incr=10&lengths_age=600&lengths_incr=10&msg_rates_age=600&msg_rates_incr=10"
+	if query != expected {
+		t.Errorf("unexpected query %s", query)
+	}
+
+	if len(queue.MessagesDetails.Samples) != 2 || queue.MessageStats.PublishDetails.Samples[0].Sample != 10 {
+		t.Errorf("unexpected samples %+v", queue)
+	}
+
+	if _, err := r.GetQueue("/", "jobs"); err != nil || query != "" {
+		t.Errorf("expected no query without options, got %q (%v)", query, err)
+	}
+
+	if _, err := r.GetQueue("/", "jobs", StatsOptions{LengthsAge: 90 * time.Second, LengthsIncr: 500 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if query != "lengths_age=90&lengths_incr=1" {
+		t.Errorf("expected sub-second increment rounded up, got %q", query)
+	}
+}
@@ -0,0 +1,122 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// openStream sends a GET request for endpoint and returns the response body
+// without reading it. The request is retried like doRequest; once the body is
+// returned, failures are not retried anymore.
+func (r *Rabbit) openStream(endpoint string) (io.ReadCloser, error) {
+	if r.Retry == nil {
+		resp, err := r.send("GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+
+	var resp *http.Response
+	err := r.Retry.do(func() error {
+		var err error
+		resp, err = r.send("GET", endpoint, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// streamList returns an iterator which decodes the json array returned by
+// endpoint one element at a time, so neither the whole response body nor the
+// whole list is held in memory. Iteration stops after the first error.
+func streamList[T any](r *Rabbit, endpoint string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		body, err := r.openStream(endpoint)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer body.Close()
+
+		dec := json.NewDecoder(body)
+		tok, err := dec.Token()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			yield(zero, fmt.Errorf("expected a json array from %s, got %v", endpoint, tok))
+			return
+		}
+
+		for dec.More() {
+			var item T
+			if err := dec.Decode(&item); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		if _, err := dec.Token(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// StreamExchanges iterates over all exchanges, decoding them one at a time
+// while the response is read. Use it instead of GetExchanges for large lists.
+func (r *Rabbit) StreamExchanges() iter.Seq2[Exchange, error] {
+	return streamList[Exchange](r, "/api/exchanges")
+}
+
+// StreamVhostExchanges iterates over all exchanges in a given virtual host,
+// decoding them one at a time.
+func (r *Rabbit) StreamVhostExchanges(vhost string) iter.Seq2[Exchange, error] {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	return streamList[Exchange](r, "/api/exchanges/"+vhost)
+}
+
+// StreamQueues iterates over all queues, decoding them one at a time while
+// the response is read. Use it instead of GetQueues for large lists.
+func (r *Rabbit) StreamQueues() iter.Seq2[Queue, error] {
+	return streamList[Queue](r, "/api/queues")
+}
+
+// StreamVhostQueues iterates over all queues in a given virtual host,
+// decoding them one at a time.
+func (r *Rabbit) StreamVhostQueues(vhost string) iter.Seq2[Queue, error] {
+	if vhost == "/" {
+		vhost = "%2f"
+	}
+
+	return streamList[Queue](r, "/api/queues/"+vhost)
+}
+
+// StreamBindings iterates over all bindings, decoding them one at a time
+// while the response is read.
+func (r *Rabbit) StreamBindings() iter.Seq2[Binding, error] {
+	return streamList[Binding](r, "/api/bindings")
+}
+
+// StreamConnections iterates over all open connections, decoding them one at
+// a time while the response is read.
+func (r *Rabbit) StreamConnections() iter.Seq2[Connection, error] {
+	return streamList[Connection](r, "/api/connections")
+}
@@ -0,0 +1,60 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRabbit_StreamQueues(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("["))
+		for i := 0; i < 1000; i++ {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"name":"q%d","vhost":"/","messages":%d}`, i, i)
+		}
+		w.Write([]byte("]"))
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+
+	count, total := 0, 0
+	for queue, err := range r.StreamQueues() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+		total += queue.Messages
+	}
+
+	if count != 1000 || total != 499500 {
+		t.Errorf("expected 1000 queues with 499500 messages, got %d and %d", count, total)
+	}
+}
+
+func TestRabbit_StreamQueuesInvalid(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"name":"q1"},{"name":`))
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+
+	var names []string
+	var lastErr error
+	for queue, err := range r.StreamQueues() {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		names = append(names, queue.Name)
+	}
+
+	if len(names) != 1 || lastErr == nil {
+		t.Errorf("expected 1 queue and an error, got %v and %v", names, lastErr)
+	}
+}
@@ -0,0 +1,105 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Tags recognised by the management plugin.
+const (
+	TagAdministrator = "administrator"
+	TagMonitoring    = "monitoring"
+	TagManagement    = "management"
+	TagPolicymaker   = "policymaker"
+	TagImpersonator  = "impersonator"
+)
+
+// Tags is the list of tags of a user. Older brokers represent tags as a comma
+// separated string, newer ones as an array; Tags decodes both forms and is
+// encoded as a comma separated string, which every broker accepts.
+type Tags []string
+
+// ParseTags parses a comma separated list of tags like "foo, bar".
+func ParseTags(s string) Tags {
+	tags := make(Tags, 0)
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func (t Tags) String() string {
+	return strings.Join(t, ",")
+}
+
+// Has reports whether tag is one of the tags.
+func (t Tags) Has(tag string) bool {
+	for _, s := range t {
+		if s == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Add returns the tags with the given tags added. Tags which are already
+// present are not added again.
+func (t Tags) Add(tags ...string) Tags {
+	result := append(Tags{}, t...)
+	for _, tag := range tags {
+		if !result.Has(tag) {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+// Remove returns the tags without the given tags.
+func (t Tags) Remove(tags ...string) Tags {
+	remove := Tags(tags)
+
+	result := make(Tags, 0, len(t))
+	for _, tag := range t {
+		if !remove.Has(tag) {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+// equal reports whether both have the same tags, in any order.
+func (t Tags) equal(o Tags) bool {
+	a := append([]string{}, t...)
+	b := append([]string{}, o...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+func (t Tags) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *Tags) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = ParseTags(s)
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*t = Tags(list)
+	return nil
+}
@@ -0,0 +1,37 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRabbit_Tags(t *testing.T) {
+	var user User
+	if err := json.Unmarshal([]byte(`{"name":"a","tags":"administrator, monitoring"}`), &user); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(user.Tags, Tags{TagAdministrator, TagMonitoring}) {
+		t.Errorf("unexpected tags from string %v", user.Tags)
+	}
+
+	if err := json.Unmarshal([]byte(`{"name":"a","tags":["management"]}`), &user); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(user.Tags, Tags{TagManagement}) {
+		t.Errorf("unexpected tags from array %v", user.Tags)
+	}
+
+	tags := user.Tags.Add(TagPolicymaker, TagManagement).Remove(TagManagement)
+	if !reflect.DeepEqual(tags, Tags{TagPolicymaker}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+
+	data, err := json.Marshal(Tags{TagAdministrator, TagImpersonator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"administrator,impersonator"` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+}
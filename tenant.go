@@ -0,0 +1,404 @@
+package rabbitapi
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Tenant describes the objects ProvisionTenant creates for a tenant: a vhost,
+// a user with permissions on it, vhost limits and a base set of exchanges.
+type Tenant struct {
+	Vhost    string
+	User     string
+	Password string
+	Tags     Tags
+
+	// Configure, Write and Read are the permission patterns of the user on
+	// the vhost. As with CreatePermission, an empty pattern grants nothing.
+	Configure string
+	Write     string
+	Read      string
+
+	// Limits are vhost limits like LimitMaxConnections.
+	Limits map[string]int
+
+	// Exchanges are declared in the vhost, keyed by name.
+	Exchanges map[string]ExchangeOptions
+}
+
+// ProvisionStep is the outcome of a single step of ProvisionTenant.
+type ProvisionStep struct {
+	Name string
+
+	// Existed is set if the object already existed in re-run mode. It's left
+	// as it is and not deleted on rollback.
+	Existed bool
+
+	// Err is nil if the step succeeded.
+	Err error
+
+	// RolledBack is set if the step was undone after a later step failed.
+	// RollbackErr is the error undoing it.
+	RolledBack  bool
+	RollbackErr error
+}
+
+// ProvisionReport describes every step taken by ProvisionTenant.
+type ProvisionReport struct {
+	Tenant string
+	Steps  []ProvisionStep
+}
+
+func (r ProvisionReport) String() string {
+	lines := make([]string, 0, len(r.Steps)+1)
+	lines = append(lines, fmt.Sprintf("provisioning of tenant '%s':", r.Tenant))
+	for _, step := range r.Steps {
+		var status string
+		switch {
+		case step.Err != nil:
+			status = step.Err.Error()
+		case step.Existed:
+			status = "exists"
+		default:
+			status = "ok"
+		}
+
+		if step.RollbackErr != nil {
+			status += ", rollback failed: " + step.RollbackErr.Error()
+		} else if step.RolledBack {
+			status += ", rolled back"
+		}
+
+		lines = append(lines, fmt.Sprintf("  %s: %s", step.Name, status))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// provisionStep is a step of the provisioning saga. undo is the compensating
+// action of create.
+type provisionStep struct {
+	name   string
+	exists func() (bool, error)
+	create func() error
+	undo   func() error
+}
+
+// ProvisionTenant creates the vhost, user, permissions, limits and exchanges
+// of the tenant, in that order. If a step fails, the objects created so far
+// are deleted again in reverse order, so no half provisioned tenant is left
+// behind, and the error of the failed step is returned. The report contains
+// the outcome of every step, including the rollback.
+//
+// Objects which already exist make provisioning fail, to never take over the
+// objects of another tenant. With rerun set, existing objects are accepted as
+// they are instead, so provisioning can be run again after a failure or for
+// a tenant provisioned before; only the missing objects are created then and
+// only those are deleted on rollback.
+func (r *Rabbit) ProvisionTenant(t Tenant, rerun bool) (ProvisionReport, error) {
+	report := ProvisionReport{Tenant: t.Vhost}
+
+	steps := r.provisionSteps(t)
+	for i, step := range steps {
+		exists, err := step.exists()
+		if err == nil && exists && !rerun {
+			err = fmt.Errorf("%s: already exists", step.name)
+		}
+		if err == nil && !exists {
+			err = step.create()
+		}
+
+		report.Steps = append(report.Steps, ProvisionStep{Name: step.name, Existed: exists && rerun, Err: err})
+		if err != nil {
+			r.rollbackProvisioning(steps[:i], report.Steps[:i])
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// rollbackProvisioning undoes the steps which created objects, last first.
+func (r *Rabbit) rollbackProvisioning(steps []provisionStep, results []ProvisionStep) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if results[i].Existed {
+			continue
+		}
+
+		err := steps[i].undo()
+		if IsNotFound(err) {
+			err = nil // e.g. already gone with the vhost
+		}
+		results[i].RolledBack = err == nil
+		results[i].RollbackErr = err
+	}
+}
+
+func (r *Rabbit) provisionSteps(t Tenant) []provisionStep {
+	steps := []provisionStep{
+		{
+			name: "create vhost " + t.Vhost,
+			exists: func() (bool, error) {
+				_, err := r.GetVhost(t.Vhost)
+				return found(err)
+			},
+			create: func() error { return r.CreateVhost(t.Vhost) },
+			undo:   func() error { return r.DeleteVhost(t.Vhost) },
+		},
+		{
+			name: "create user " + t.User,
+			exists: func() (bool, error) {
+				_, err := r.GetUser(t.User)
+				return found(err)
+			},
+			create: func() error { return r.CreateUser(t.User, t.Password, t.Tags) },
+			undo:   func() error { return r.DeleteUser(t.User) },
+		},
+		{
+			name: "set permissions of user " + t.User,
+			exists: func() (bool, error) {
+				_, err := r.GetPermission(t.Vhost, t.User)
+				return found(err)
+			},
+			create: func() error { return r.CreatePermission(t.Vhost, t.User, t.Configure, t.Write, t.Read) },
+			undo:   func() error { return r.DeletePermission(t.Vhost, t.User) },
+		},
+	}
+
+	limits := make([]string, 0, len(t.Limits))
+	for name := range t.Limits {
+		limits = append(limits, name)
+	}
+	sort.Strings(limits)
+
+	for _, name := range limits {
+		name, value := name, t.Limits[name]
+		steps = append(steps, provisionStep{
+			name: "set limit " + name,
+			exists: func() (bool, error) {
+				current, err := r.GetVhostLimits(t.Vhost)
+				if err != nil {
+					return false, err
+				}
+				_, ok := current[name]
+				return ok, nil
+			},
+			create: func() error { return r.SetVhostLimit(t.Vhost, name, value) },
+			undo:   func() error { return r.DeleteVhostLimit(t.Vhost, name) },
+		})
+	}
+
+	exchanges := make([]string, 0, len(t.Exchanges))
+	for name := range t.Exchanges {
+		exchanges = append(exchanges, name)
+	}
+	sort.Strings(exchanges)
+
+	for _, name := range exchanges {
+		name, opts := name, t.Exchanges[name]
+		steps = append(steps, provisionStep{
+			name: "create exchange " + name,
+			exists: func() (bool, error) {
+				_, err := r.GetExchange(t.Vhost, name)
+				return found(err)
+			},
+			create: func() error { return r.CreateExchange(t.Vhost, name, opts) },
+			undo:   func() error { return r.DeleteExchange(t.Vhost, name) },
+		})
+	}
+
+	return steps
+}
+
+// found converts the error of a get call into whether the object exists.
+func found(err error) (bool, error) {
+	if IsNotFound(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// DeprovisionOptions configure DeprovisionTenant.
+type DeprovisionOptions struct {
+	// CloseConnections closes the live connections to the vhost. Without
+	// it, live connections make deprovisioning fail.
+	CloseConnections bool
+
+	// DiscardMessages allows deleting queues which still have messages.
+	// Without it, non-empty queues make deprovisioning fail.
+	DiscardMessages bool
+
+	// Backup, if set, receives the definitions of the vhost as exported by
+	// the broker (JSON) before anything is deleted. They can be imported
+	// again on the management UI. Needs RabbitMQ 3.7 or later.
+	Backup io.Writer
+}
+
+// DeprovisionStep is the outcome of a single step of DeprovisionTenant. Err
+// is nil if the step succeeded or was skipped.
+type DeprovisionStep struct {
+	Name string
+	Err  error
+
+	// SkipReason is set if the step was not taken, e.g. "administrator".
+	SkipReason string
+}
+
+// DeprovisionReport describes every step taken by DeprovisionTenant.
+type DeprovisionReport struct {
+	Vhost string
+	Steps []DeprovisionStep
+}
+
+func (r DeprovisionReport) String() string {
+	lines := make([]string, 0, len(r.Steps)+1)
+	lines = append(lines, fmt.Sprintf("deprovisioning of tenant '%s':", r.Vhost))
+	for _, step := range r.Steps {
+		switch {
+		case step.SkipReason != "":
+			lines = append(lines, fmt.Sprintf("  %s: skipped (%s)", step.Name, step.SkipReason))
+		case step.Err != nil:
+			lines = append(lines, fmt.Sprintf("  %s: %s", step.Name, step.Err))
+		default:
+			lines = append(lines, fmt.Sprintf("  %s: ok", step.Name))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (r *DeprovisionReport) add(name string, err error) error {
+	r.Steps = append(r.Steps, DeprovisionStep{Name: name, Err: err})
+	return err
+}
+
+// DeprovisionTenant deletes the vhost of a tenant and the users whose only
+// permissions are on that vhost. It first checks that the vhost has no live
+// connections and no messages in its queues (see DeprovisionOptions), and
+// optionally writes a backup of its definitions. The user of r and users
+// tagged administrator are never deleted, the latter are reported as skipped
+// steps. The default vhost "/" is not a tenant and can't be deprovisioned.
+// The report contains the outcome of every step taken; deprovisioning stops
+// at the first failed step, whose error is returned.
+func (r *Rabbit) DeprovisionTenant(vhost string, opts DeprovisionOptions) (DeprovisionReport, error) {
+	report := DeprovisionReport{Vhost: vhost}
+	if vhost == "/" || vhost == "" {
+		return report, fmt.Errorf("vhost '%s' is not a tenant vhost", vhost)
+	}
+
+	connections, err := r.GetConnections()
+	if report.add("list connections", err) != nil {
+		return report, err
+	}
+
+	live := make([]Connection, 0)
+	for _, c := range connections {
+		if c.Vhost == vhost {
+			live = append(live, c)
+		}
+	}
+	if len(live) != 0 && !opts.CloseConnections {
+		err := fmt.Errorf("vhost '%s' has %d live connections", vhost, len(live))
+		return report, report.add("check connections", err)
+	}
+
+	queues, err := r.GetVhostQueues(vhost)
+	if report.add("list queues", err) != nil {
+		return report, err
+	}
+
+	if !opts.DiscardMessages {
+		for _, q := range queues {
+			if q.Messages != 0 {
+				err := fmt.Errorf("queue '%s' has %d messages", q.Name, q.Messages)
+				return report, report.add("check queues", err)
+			}
+		}
+	}
+
+	users, err := r.tenantUsers(vhost)
+	if report.add("list users", err) != nil {
+		return report, err
+	}
+
+	if opts.Backup != nil {
+		definitions, err := r.vhostDefinitions(vhost)
+		if err == nil {
+			_, err = opts.Backup.Write(definitions)
+		}
+		if report.add("backup definitions", err) != nil {
+			return report, err
+		}
+	}
+
+	for _, c := range live {
+		err := r.CloseConnection(c.Name)
+		if IsNotFound(err) {
+			err = nil // closed in the meantime
+		}
+		if report.add("close connection "+c.Name, err) != nil {
+			return report, err
+		}
+	}
+
+	err = r.DeleteVhost(vhost)
+	if report.add("delete vhost "+vhost, err) != nil {
+		return report, err
+	}
+
+	for _, user := range users {
+		if user.Tags.Has(TagAdministrator) {
+			report.Steps = append(report.Steps, DeprovisionStep{Name: "delete user " + user.Name, SkipReason: "administrator"})
+			continue
+		}
+
+		err := r.DeleteUser(user.Name)
+		if report.add("delete user "+user.Name, err) != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// tenantUsers returns the users whose only permissions are on vhost, except
+// the user of r.
+func (r *Rabbit) tenantUsers(vhost string) ([]User, error) {
+	permissions, err := r.GetVhostPermissions(vhost)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]User, 0)
+	for _, p := range permissions {
+		if p.User == r.Username {
+			continue
+		}
+
+		userPermissions, err := r.GetUserPermissions(p.User)
+		if err != nil {
+			return nil, err
+		}
+
+		only := true
+		for _, up := range userPermissions {
+			if up.Vhost != vhost {
+				only = false
+			}
+		}
+		if !only {
+			continue
+		}
+
+		user, err := r.GetUser(p.User)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
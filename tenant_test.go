@@ -0,0 +1,276 @@
+package rabbitapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeBroker keeps the objects put to it, keyed by path, and fails puts to
+// the path in fail.
+type fakeBroker struct {
+	mu       sync.Mutex
+	objects  map[string]bool
+	limits   map[string]int
+	fail     string
+	requests []string
+}
+
+func (b *fakeBroker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	path := req.URL.EscapedPath()
+	if req.Method != "GET" {
+		b.requests = append(b.requests, req.Method+" "+path)
+	}
+
+	if strings.HasPrefix(path, "/api/vhost-limits/") {
+		parts := strings.Split(path, "/")
+		switch req.Method {
+		case "GET":
+			json.NewEncoder(w).Encode([]vhostLimits{{Vhost: parts[3], Value: b.limits}})
+		case "PUT":
+			var body struct{ Value int }
+			json.NewDecoder(req.Body).Decode(&body)
+			b.limits[parts[4]] = body.Value
+			w.WriteHeader(http.StatusNoContent)
+		case "DELETE":
+			delete(b.limits, parts[4])
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	switch req.Method {
+	case "GET":
+		if !b.objects[path] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	case "PUT":
+		if path == b.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		b.objects[path] = true
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		if !b.objects[path] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(b.objects, path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func testTenant() Tenant {
+	return Tenant{
+		Vhost:     "tenant",
+		User:      "app",
+		Password:  "secret",
+		Configure: "^app\\.", Write: ".*", Read: ".*",
+		Limits: map[string]int{LimitMaxConnections: 10},
+		Exchanges: map[string]ExchangeOptions{
+			"events": {Type: ExchangeTopic, Durable: true},
+			"audit":  {Type: ExchangeFanout, Durable: true},
+		},
+	}
+}
+
+func TestRabbit_ProvisionTenantRollback(t *testing.T) {
+	broker := &fakeBroker{
+		objects: make(map[string]bool),
+		limits:  make(map[string]int),
+		fail:    "/api/exchanges/tenant/events",
+	}
+	ts := httptest.NewServer(broker)
+	defer ts.Close()
+
+	report, err := Auth("guest", "guest", ts.URL).ProvisionTenant(testTenant(), false)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	want := []string{
+		"PUT /api/vhosts/tenant",
+		"PUT /api/users/app",
+		"PUT /api/permissions/tenant/app",
+		"PUT /api/vhost-limits/tenant/max-connections",
+		"PUT /api/exchanges/tenant/audit",
+		"PUT /api/exchanges/tenant/events",
+		"DELETE /api/exchanges/tenant/audit",
+		"DELETE /api/vhost-limits/tenant/max-connections",
+		"DELETE /api/permissions/tenant/app",
+		"DELETE /api/users/app",
+		"DELETE /api/vhosts/tenant",
+	}
+	if !reflect.DeepEqual(broker.requests, want) {
+		t.Errorf("got requests\n%q\nwant\n%q", broker.requests, want)
+	}
+
+	if len(broker.objects) != 0 || len(broker.limits) != 0 {
+		t.Errorf("objects left behind: %v %v", broker.objects, broker.limits)
+	}
+
+	if !strings.Contains(report.String(), "create vhost tenant: ok, rolled back") {
+		t.Errorf("unexpected report\n%s", report)
+	}
+}
+
+func TestRabbit_ProvisionTenantRerun(t *testing.T) {
+	broker := &fakeBroker{
+		objects: map[string]bool{"/api/vhosts/tenant": true, "/api/users/app": true},
+		limits:  make(map[string]int),
+	}
+	ts := httptest.NewServer(broker)
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	if _, err := r.ProvisionTenant(testTenant(), false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected an already exists error, got %v", err)
+	}
+
+	broker.requests = nil
+	report, err := r.ProvisionTenant(testTenant(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"PUT /api/permissions/tenant/app",
+		"PUT /api/vhost-limits/tenant/max-connections",
+		"PUT /api/exchanges/tenant/audit",
+		"PUT /api/exchanges/tenant/events",
+	}
+	if !reflect.DeepEqual(broker.requests, want) {
+		t.Errorf("got requests\n%q\nwant\n%q", broker.requests, want)
+	}
+
+	if !report.Steps[0].Existed || !report.Steps[1].Existed || report.Steps[2].Existed {
+		t.Errorf("unexpected report\n%s", report)
+	}
+
+	// everything exists now, a further run changes nothing
+	broker.requests = nil
+	if _, err := r.ProvisionTenant(testTenant(), true); err != nil || len(broker.requests) != 0 {
+		t.Errorf("second rerun: err %v, requests %q", err, broker.requests)
+	}
+}
+
+// deprovisionServer serves a tenant vhost with a connection and a queue with
+// the given number of messages, and records the changing requests.
+func deprovisionServer(t *testing.T, messages int) (*httptest.Server, *[]string) {
+	requests := make([]string, 0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path := req.URL.EscapedPath()
+		if req.Method != "GET" {
+			requests = append(requests, req.Method+" "+path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		switch path {
+		case "/api/connections":
+			w.Write([]byte(`[{"name":"10.0.0.1:5000 -> 10.0.0.2:5672","vhost":"tenant","user":"app"},{"name":"other","vhost":"/","user":"admin"}]`))
+		case "/api/queues/tenant":
+			json.NewEncoder(w).Encode([]map[string]interface{}{{"name": "jobs", "vhost": "tenant", "messages": messages}})
+		case "/api/vhosts/tenant/permissions":
+			w.Write([]byte(`[{"user":"app","vhost":"tenant"},{"user":"shared","vhost":"tenant"},{"user":"admin","vhost":"tenant"},{"user":"ops","vhost":"tenant"}]`))
+		case "/api/users/app/permissions":
+			w.Write([]byte(`[{"user":"app","vhost":"tenant"}]`))
+		case "/api/users/shared/permissions":
+			w.Write([]byte(`[{"user":"shared","vhost":"tenant"},{"user":"shared","vhost":"other"}]`))
+		case "/api/users/admin/permissions":
+			w.Write([]byte(`[{"user":"admin","vhost":"tenant"}]`))
+		case "/api/users/ops/permissions":
+			w.Write([]byte(`[{"user":"ops","vhost":"tenant"}]`))
+		case "/api/users/app":
+			w.Write([]byte(`{"name":"app","tags":""}`))
+		case "/api/users/ops":
+			w.Write([]byte(`{"name":"ops","tags":"administrator"}`))
+		case "/api/definitions/tenant":
+			w.Write([]byte(`{"exchanges":[{"name":"events","type":"topic"}]}`))
+		default:
+			t.Errorf("unexpected request %s", path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, &requests
+}
+
+func TestRabbit_DeprovisionTenant(t *testing.T) {
+	ts, requests := deprovisionServer(t, 0)
+	r := Auth("admin", "admin", ts.URL)
+
+	_, err := r.DeprovisionTenant("tenant", DeprovisionOptions{})
+	if err == nil || !strings.Contains(err.Error(), "1 live connections") {
+		t.Fatalf("expected a live connections error, got %v", err)
+	}
+	if len(*requests) != 0 {
+		t.Fatalf("failed check changed the broker: %q", *requests)
+	}
+
+	var backup strings.Builder
+	report, err := r.DeprovisionTenant("tenant", DeprovisionOptions{CloseConnections: true, Backup: &backup})
+	if err != nil {
+		t.Fatalf("%s\n%s", err, report)
+	}
+
+	want := []string{
+		"DELETE /api/connections/10.0.0.1:5000%20-%3E%2010.0.0.2:5672",
+		"DELETE /api/vhosts/tenant",
+		"DELETE /api/users/app",
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests\n%q\nwant\n%q", *requests, want)
+	}
+
+	if !strings.Contains(backup.String(), `"events"`) {
+		t.Errorf("unexpected backup %q", backup.String())
+	}
+
+	if !strings.Contains(report.String(), "delete user ops: skipped (administrator)") {
+		t.Errorf("administrator not skipped:\n%s", report)
+	}
+}
+
+func TestRabbit_DeprovisionTenantMessages(t *testing.T) {
+	ts, requests := deprovisionServer(t, 5)
+	r := Auth("admin", "admin", ts.URL)
+
+	_, err := r.DeprovisionTenant("tenant", DeprovisionOptions{CloseConnections: true})
+	if err == nil || !strings.Contains(err.Error(), "queue 'jobs' has 5 messages") {
+		t.Fatalf("expected a non-empty queue error, got %v", err)
+	}
+	if len(*requests) != 0 {
+		t.Fatalf("failed check changed the broker: %q", *requests)
+	}
+}
+
+func TestRabbit_DeprovisionTenantDefaultVhost(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}))
+	defer ts.Close()
+
+	r := Auth("admin", "admin", ts.URL)
+	for _, vhost := range []string{"/", ""} {
+		report, err := r.DeprovisionTenant(vhost, DeprovisionOptions{CloseConnections: true, DiscardMessages: true})
+		if err == nil || !strings.Contains(err.Error(), "is not a tenant vhost") {
+			t.Errorf("%q: expected an error, got %v", vhost, err)
+		}
+		if len(report.Steps) != 0 {
+			t.Errorf("%q: unexpected steps %v", vhost, report.Steps)
+		}
+	}
+}
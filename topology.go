@@ -0,0 +1,610 @@
+package rabbitapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Topology describes the desired state of a broker. It's used by Plan and
+// Reconcile to bring a broker into the described state.
+type Topology struct {
+	Vhosts      []Vhost
+	Users       []User
+	Permissions []Permission
+	Exchanges   []Exchange
+	Queues      []Queue
+	Bindings    []Binding
+	Policies    []Policy
+}
+
+type ChangeAction string
+
+const (
+	ChangeCreate ChangeAction = "create"
+	ChangeUpdate ChangeAction = "update"
+	ChangeDelete ChangeAction = "delete"
+
+	// ChangeRecreate deletes an exchange or queue and declares it again
+	// with the desired properties. The bindings of the exchange or queue,
+	// and the messages of the queue, are lost.
+	ChangeRecreate ChangeAction = "recreate"
+
+	// ChangeNotApplicable is an exchange or queue whose properties differ
+	// from the desired ones but which is not recreated, see
+	// ReconcileOptions.Recreate. Reconcile refuses to run while there are
+	// such changes.
+	ChangeNotApplicable ChangeAction = "cannot update"
+)
+
+// ReconcileOptions configure Plan and Reconcile.
+type ReconcileOptions struct {
+	// Recreate allows deleting and declaring again exchanges and queues
+	// whose properties (type, durable, auto delete, internal or arguments)
+	// differ, as they can't be changed in place. This drops all their
+	// bindings, including those the topology doesn't own, and the messages
+	// of the queues.
+	Recreate bool
+}
+
+// Change is a single step needed to bring the broker into the desired
+// topology.
+type Change struct {
+	Action ChangeAction
+	Kind   string // vhost, user, permission, exchange, queue, binding or policy
+	Vhost  string
+	Name   string
+
+	apply func(r *Rabbit) error
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.Name)
+	if c.Vhost != "" {
+		s += " in vhost " + c.Vhost
+	}
+
+	switch {
+	case c.Action == ChangeRecreate && c.Kind == "queue":
+		s += " (drops its messages and bindings)"
+	case c.Action == ChangeRecreate:
+		s += " (drops its bindings)"
+	case c.Action == ChangeNotApplicable:
+		s += " (properties differ, needs Recreate)"
+	}
+
+	return s
+}
+
+// Plan compares the current state of the broker with desired and returns the
+// changes Reconcile would apply, without applying them.
+//
+// Only the vhosts listed in desired are managed: exchanges, queues, bindings,
+// policies and permissions in those vhosts which are not part of desired are
+// deleted. Vhosts and users are only created or updated, never deleted. The
+// default exchanges ("" and "amq.*") are never touched.
+//
+// Exchanges and queues with different properties can't be updated in place.
+// They are planned as ChangeRecreate if opts.Recreate is set and as
+// ChangeNotApplicable otherwise.
+func (r *Rabbit) Plan(ctx context.Context, desired Topology, opts ReconcileOptions) ([]Change, error) {
+	current, err := r.currentTopology(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return planChanges(current, desired, opts), nil
+}
+
+// Reconcile brings the broker into the desired topology. Changes are applied
+// in dependency order (vhosts, users, permissions, exchanges, queues,
+// bindings and policies, followed by deletes in reverse order). It returns
+// the changes that were applied; on failure the failed change is not part of
+// the returned list.
+//
+// Exchanges and queues with different properties are only deleted and
+// declared again if opts.Recreate is set. Otherwise Reconcile changes nothing
+// and returns an error naming them.
+func (r *Rabbit) Reconcile(ctx context.Context, desired Topology, opts ReconcileOptions) ([]Change, error) {
+	changes, err := r.Plan(ctx, desired, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var notApplicable []string
+	for _, change := range changes {
+		if change.Action == ChangeNotApplicable {
+			notApplicable = append(notApplicable, fmt.Sprintf("%s %s in vhost %s", change.Kind, change.Name, change.Vhost))
+		}
+	}
+	if len(notApplicable) != 0 {
+		return nil, fmt.Errorf("properties of %s differ and can't be changed in place, set ReconcileOptions.Recreate to delete and declare them again",
+			strings.Join(notApplicable, ", "))
+	}
+
+	applied := make([]Change, 0, len(changes))
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return applied, err
+		}
+
+		if err := change.apply(r); err != nil {
+			return applied, fmt.Errorf("%s: %s", change, err)
+		}
+
+		applied = append(applied, change)
+	}
+
+	return applied, nil
+}
+
+// currentTopology reads the whole topology of the broker.
+func (r *Rabbit) currentTopology(ctx context.Context) (Topology, error) {
+	var t Topology
+	var err error
+
+	steps := []func(){
+		func() { t.Vhosts, err = r.GetVhosts() },
+		func() { t.Users, err = r.GetUsers() },
+		func() { t.Permissions, err = r.GetPermissions() },
+		func() { t.Exchanges, err = r.GetExchanges() },
+		func() { t.Queues, err = r.GetQueues() },
+		func() { t.Bindings, err = r.GetBindings() },
+		func() { t.Policies, err = r.GetPolicies() },
+	}
+
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return Topology{}, err
+		}
+
+		step()
+		if err != nil {
+			return Topology{}, err
+		}
+	}
+
+	return t, nil
+}
+
+// planChanges computes the changes needed to go from current to desired.
+func planChanges(current, desired Topology, opts ReconcileOptions) []Change {
+	managed := make(map[string]bool)
+	for _, v := range desired.Vhosts {
+		managed[v.Name] = true
+	}
+
+	var creates, deletes []Change
+
+	// vhosts
+	vhosts := make(map[string]bool)
+	for _, v := range current.Vhosts {
+		vhosts[v.Name] = true
+	}
+	for _, v := range desired.Vhosts {
+		if !vhosts[v.Name] {
+			creates = append(creates, createVhostChange(v))
+		}
+	}
+
+	// users
+	users := make(map[string]User)
+	for _, u := range current.Users {
+		users[u.Name] = u
+	}
+	for _, u := range desired.Users {
+		cur, ok := users[u.Name]
+		if !ok {
+			creates = append(creates, createUserChange(u))
+		} else if !cur.Tags.equal(u.Tags) {
+			creates = append(creates, updateUserChange(cur, u))
+		}
+	}
+
+	// permissions
+	permissions := make(map[string]Permission)
+	for _, p := range current.Permissions {
+		permissions[p.Vhost+"\x00"+p.User] = p
+	}
+	wantPermissions := make(map[string]bool)
+	for _, p := range desired.Permissions {
+		key := p.Vhost + "\x00" + p.User
+		wantPermissions[key] = true
+
+		cur, ok := permissions[key]
+		switch {
+		case !ok:
+			creates = append(creates, setPermissionChange(ChangeCreate, p))
+		case cur.Configure != p.Configure || cur.Write != p.Write || cur.Read != p.Read:
+			creates = append(creates, setPermissionChange(ChangeUpdate, p))
+		}
+	}
+	for _, p := range current.Permissions {
+		if managed[p.Vhost] && !wantPermissions[p.Vhost+"\x00"+p.User] {
+			deletes = append(deletes, deletePermissionChange(p))
+		}
+	}
+
+	// updateAction is the action for exchanges and queues with different
+	// properties.
+	updateAction := ChangeNotApplicable
+	if opts.Recreate {
+		updateAction = ChangeRecreate
+	}
+
+	// exchanges. removed is the set of exchanges and queues which are
+	// deleted or declared again, their bindings are removed by the broker.
+	removed := make(map[string]bool)
+	exchanges := make(map[string]Exchange)
+	for _, e := range current.Exchanges {
+		exchanges[e.Vhost+"\x00"+e.Name] = e
+	}
+	wantExchanges := make(map[string]bool)
+	for _, e := range desired.Exchanges {
+		key := e.Vhost + "\x00" + e.Name
+		wantExchanges[key] = true
+
+		cur, ok := exchanges[key]
+		switch {
+		case !ok:
+			creates = append(creates, createExchangeChange(ChangeCreate, e))
+		case !equalExchange(cur, e):
+			if opts.Recreate {
+				removed["e\x00"+key] = true
+			}
+			creates = append(creates, createExchangeChange(updateAction, e))
+		}
+	}
+	for _, e := range current.Exchanges {
+		key := e.Vhost + "\x00" + e.Name
+		if managed[e.Vhost] && !wantExchanges[key] && !isDefaultExchange(e.Name) {
+			removed["e\x00"+key] = true
+			deletes = append(deletes, deleteExchangeChange(e))
+		}
+	}
+
+	// queues
+	queues := make(map[string]Queue)
+	for _, q := range current.Queues {
+		queues[q.Vhost+"\x00"+q.Name] = q
+	}
+	wantQueues := make(map[string]bool)
+	for _, q := range desired.Queues {
+		key := q.Vhost + "\x00" + q.Name
+		wantQueues[key] = true
+
+		cur, ok := queues[key]
+		switch {
+		case !ok:
+			creates = append(creates, createQueueChange(ChangeCreate, q))
+		case !equalQueue(cur, q):
+			if opts.Recreate {
+				removed["q\x00"+key] = true
+			}
+			creates = append(creates, createQueueChange(updateAction, q))
+		}
+	}
+	for _, q := range current.Queues {
+		key := q.Vhost + "\x00" + q.Name
+		if managed[q.Vhost] && !wantQueues[key] {
+			removed["q\x00"+key] = true
+			deletes = append(deletes, deleteQueueChange(q))
+		}
+	}
+
+	// bindings
+	bindings := make(map[string]Binding)
+	for _, b := range current.Bindings {
+		if b.Source == "" {
+			continue // implicit bindings of the default exchange
+		}
+		if removed["e\x00"+b.Vhost+"\x00"+b.Source] ||
+			removed[bindingDestination(b.DestinationType)+"\x00"+b.Vhost+"\x00"+b.Destination] {
+			continue
+		}
+		bindings[bindingKey(b)] = b
+	}
+	wantBindings := make(map[string]bool)
+	for _, b := range desired.Bindings {
+		key := bindingKey(b)
+		wantBindings[key] = true
+
+		if _, ok := bindings[key]; !ok {
+			creates = append(creates, createBindingChange(b))
+		}
+	}
+	for key, b := range bindings {
+		if managed[b.Vhost] && !wantBindings[key] {
+			deletes = append(deletes, deleteBindingChange(b))
+		}
+	}
+
+	// policies
+	policies := make(map[string]Policy)
+	for _, p := range current.Policies {
+		policies[p.Vhost+"\x00"+p.Name] = p
+	}
+	wantPolicies := make(map[string]bool)
+	for _, p := range desired.Policies {
+		key := p.Vhost + "\x00" + p.Name
+		wantPolicies[key] = true
+
+		cur, ok := policies[key]
+		switch {
+		case !ok:
+			creates = append(creates, createPolicyChange(ChangeCreate, p))
+		case !equalPolicy(cur, p):
+			creates = append(creates, createPolicyChange(ChangeUpdate, p))
+		}
+	}
+	for _, p := range current.Policies {
+		if managed[p.Vhost] && !wantPolicies[p.Vhost+"\x00"+p.Name] {
+			deletes = append(deletes, deletePolicyChange(p))
+		}
+	}
+
+	sort.SliceStable(creates, func(i, j int) bool {
+		return kindOrder[creates[i].Kind] < kindOrder[creates[j].Kind]
+	})
+	sort.SliceStable(deletes, func(i, j int) bool {
+		if deletes[i].Kind != deletes[j].Kind {
+			return kindOrder[deletes[i].Kind] > kindOrder[deletes[j].Kind]
+		}
+		return deletes[i].String() < deletes[j].String()
+	})
+
+	return append(creates, deletes...)
+}
+
+// kindOrder is the order in which objects are created. Deletes happen in the
+// reverse order.
+var kindOrder = map[string]int{
+	"vhost":      0,
+	"user":       1,
+	"permission": 2,
+	"exchange":   3,
+	"queue":      4,
+	"binding":    5,
+	"policy":     6,
+}
+
+func createVhostChange(v Vhost) Change {
+	return Change{
+		Action: ChangeCreate,
+		Kind:   "vhost",
+		Name:   v.Name,
+		apply: func(r *Rabbit) error {
+			return r.CreateVhost(v.Name)
+		},
+	}
+}
+
+func createUserChange(u User) Change {
+	return Change{
+		Action: ChangeCreate,
+		Kind:   "user",
+		Name:   u.Name,
+		apply: func(r *Rabbit) error {
+			if u.PasswordHash == "" {
+				return r.CreateUser(u.Name, u.Password, u.Tags)
+			}
+
+			return r.CreateUserWithHash(u.Name, u.PasswordHash, u.HashingAlgorithm, u.Tags)
+		},
+	}
+}
+
+// updateUserChange changes the tags of an existing user, keeping the current
+// password.
+func updateUserChange(cur, u User) Change {
+	return Change{
+		Action: ChangeUpdate,
+		Kind:   "user",
+		Name:   u.Name,
+		apply: func(r *Rabbit) error {
+			return r.CreateUserWithHash(u.Name, cur.PasswordHash, cur.HashingAlgorithm, u.Tags)
+		},
+	}
+}
+
+func setPermissionChange(action ChangeAction, p Permission) Change {
+	return Change{
+		Action: action,
+		Kind:   "permission",
+		Vhost:  p.Vhost,
+		Name:   p.User,
+		apply: func(r *Rabbit) error {
+			return r.CreatePermission(p.Vhost, p.User, p.Configure, p.Write, p.Read)
+		},
+	}
+}
+
+func deletePermissionChange(p Permission) Change {
+	return Change{
+		Action: ChangeDelete,
+		Kind:   "permission",
+		Vhost:  p.Vhost,
+		Name:   p.User,
+		apply: func(r *Rabbit) error {
+			return r.DeletePermission(p.Vhost, p.User)
+		},
+	}
+}
+
+func createExchangeChange(action ChangeAction, e Exchange) Change {
+	return Change{
+		Action: action,
+		Kind:   "exchange",
+		Vhost:  e.Vhost,
+		Name:   e.Name,
+		apply: func(r *Rabbit) error {
+			switch action {
+			case ChangeNotApplicable:
+				return errors.New("exchange properties differ, it must be recreated")
+			case ChangeRecreate:
+				if err := r.DeleteExchange(e.Vhost, e.Name); err != nil {
+					return err
+				}
+			}
+
+			return r.CreateExchange(e.Vhost, e.Name, e.Options())
+		},
+	}
+}
+
+func deleteExchangeChange(e Exchange) Change {
+	return Change{
+		Action: ChangeDelete,
+		Kind:   "exchange",
+		Vhost:  e.Vhost,
+		Name:   e.Name,
+		apply: func(r *Rabbit) error {
+			return r.DeleteExchange(e.Vhost, e.Name)
+		},
+	}
+}
+
+func createQueueChange(action ChangeAction, q Queue) Change {
+	return Change{
+		Action: action,
+		Kind:   "queue",
+		Vhost:  q.Vhost,
+		Name:   q.Name,
+		apply: func(r *Rabbit) error {
+			switch action {
+			case ChangeNotApplicable:
+				return errors.New("queue properties differ, it must be recreated")
+			case ChangeRecreate:
+				if err := r.DeleteQueue(q.Vhost, q.Name); err != nil {
+					return err
+				}
+			}
+
+			return r.CreateQueue(q.Vhost, q.Name, q.Durable, q.AutoDelete, q.Arguments)
+		},
+	}
+}
+
+func deleteQueueChange(q Queue) Change {
+	return Change{
+		Action: ChangeDelete,
+		Kind:   "queue",
+		Vhost:  q.Vhost,
+		Name:   q.Name,
+		apply: func(r *Rabbit) error {
+			return r.DeleteQueue(q.Vhost, q.Name)
+		},
+	}
+}
+
+func createBindingChange(b Binding) Change {
+	return Change{
+		Action: ChangeCreate,
+		Kind:   "binding",
+		Vhost:  b.Vhost,
+		Name:   bindingName(b),
+		apply: func(r *Rabbit) error {
+			return r.CreateBinding(b.Vhost, b.Source, b.Destination, b.DestinationType, b.RoutingKey, b.Arguments)
+		},
+	}
+}
+
+func deleteBindingChange(b Binding) Change {
+	return Change{
+		Action: ChangeDelete,
+		Kind:   "binding",
+		Vhost:  b.Vhost,
+		Name:   bindingName(b),
+		apply: func(r *Rabbit) error {
+			return r.DeleteBinding(b.Vhost, b.Source, b.Destination, b.DestinationType, b.PropertiesKey)
+		},
+	}
+}
+
+func createPolicyChange(action ChangeAction, p Policy) Change {
+	return Change{
+		Action: action,
+		Kind:   "policy",
+		Vhost:  p.Vhost,
+		Name:   p.Name,
+		apply: func(r *Rabbit) error {
+			return r.CreatePolicy(p.Vhost, p.Name, p.Pattern, p.ApplyTo, p.Definition, p.Priority)
+		},
+	}
+}
+
+func deletePolicyChange(p Policy) Change {
+	return Change{
+		Action: ChangeDelete,
+		Kind:   "policy",
+		Vhost:  p.Vhost,
+		Name:   p.Name,
+		apply: func(r *Rabbit) error {
+			return r.DeletePolicy(p.Vhost, p.Name)
+		},
+	}
+}
+
+func isDefaultExchange(name string) bool {
+	return name == "" || strings.HasPrefix(name, "amq.")
+}
+
+func bindingName(b Binding) string {
+	return fmt.Sprintf("%s -> %s %s (%q)", b.Source, b.DestinationType, b.Destination, b.RoutingKey)
+}
+
+func bindingKey(b Binding) string {
+	destinationType := b.DestinationType
+	if destinationType == "" {
+		destinationType = DestinationQueue
+	}
+
+	return strings.Join([]string{b.Vhost, b.Source, string(destinationType), b.Destination, b.RoutingKey, argumentsKey(b.Arguments)}, "\x00")
+}
+
+func equalExchange(a, b Exchange) bool {
+	return a.Type == b.Type &&
+		a.Durable == b.Durable &&
+		a.AutoDelete == b.AutoDelete &&
+		a.Internal == b.Internal &&
+		argumentsKey(a.Arguments) == argumentsKey(b.Arguments)
+}
+
+func equalQueue(a, b Queue) bool {
+	return a.Durable == b.Durable &&
+		a.AutoDelete == b.AutoDelete &&
+		argumentsKey(a.Arguments) == argumentsKey(b.Arguments)
+}
+
+func equalPolicy(a, b Policy) bool {
+	applyTo := func(s string) string {
+		if s == "" {
+			return "all"
+		}
+		return s
+	}
+
+	return a.Pattern == b.Pattern &&
+		applyTo(a.ApplyTo) == applyTo(b.ApplyTo) &&
+		a.Priority == b.Priority &&
+		argumentsKey(a.Definition) == argumentsKey(b.Definition)
+}
+
+// argumentsKey returns a canonical form of args, so that arguments decoded
+// from the api (where numbers are float64) and arguments given by the user
+// compare equal. nil and empty arguments are the same.
+func argumentsKey(args map[string]interface{}) string {
+	if len(args) == 0 {
+		return "{}"
+	}
+
+	data, err := json.Marshal(args) // map keys are sorted by encoding/json
+	if err != nil {
+		return fmt.Sprint(args)
+	}
+
+	return string(data)
+}
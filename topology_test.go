@@ -0,0 +1,114 @@
+package rabbitapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRabbit_PlanChanges(t *testing.T) {
+	current := Topology{
+		Vhosts: []Vhost{{Name: "/"}, {Name: "tenant"}},
+		Users:  []User{{Name: "guest", Tags: Tags{TagAdministrator}}, {Name: "app"}},
+		Exchanges: []Exchange{
+			{Vhost: "tenant", Name: "amq.topic", Type: "topic", Durable: true},
+			{Vhost: "tenant", Name: "events", Type: "fanout", Durable: true},
+			{Vhost: "tenant", Name: "stale", Type: "direct"},
+			{Vhost: "/", Name: "unmanaged", Type: "direct"},
+		},
+		Queues: []Queue{
+			{Vhost: "tenant", Name: "jobs", Durable: true, Arguments: map[string]interface{}{"x-max-length": float64(10)}},
+		},
+		Bindings: []Binding{
+			{Vhost: "tenant", Source: "", Destination: "jobs", DestinationType: DestinationQueue, RoutingKey: "jobs"},
+			{Vhost: "tenant", Source: "events", Destination: "jobs", DestinationType: DestinationQueue, RoutingKey: "#"},
+		},
+	}
+
+	desired := Topology{
+		Vhosts: []Vhost{{Name: "tenant"}, {Name: "new"}},
+		Users:  []User{{Name: "app", Tags: Tags{TagMonitoring}}},
+		Permissions: []Permission{
+			{Vhost: "tenant", User: "app", Configure: ".*", Write: ".*", Read: ".*"},
+		},
+		Exchanges: []Exchange{
+			{Vhost: "tenant", Name: "events", Type: "topic", Durable: true},
+		},
+		Queues: []Queue{
+			{Vhost: "tenant", Name: "jobs", Durable: true, Arguments: map[string]interface{}{"x-max-length": 10}},
+		},
+		Bindings: []Binding{
+			{Vhost: "tenant", Source: "events", Destination: "jobs", DestinationType: DestinationQueue, RoutingKey: "#"},
+		},
+	}
+
+	tests := []struct {
+		opts     ReconcileOptions
+		expected []string
+	}{
+		{
+			ReconcileOptions{},
+			[]string{
+				"create vhost new",
+				"update user app",
+				"create permission app in vhost tenant",
+				"cannot update exchange events in vhost tenant (properties differ, needs Recreate)",
+				"delete exchange stale in vhost tenant",
+			},
+		},
+		{
+			ReconcileOptions{Recreate: true},
+			[]string{
+				"create vhost new",
+				"update user app",
+				"create permission app in vhost tenant",
+				"recreate exchange events in vhost tenant (drops its bindings)",
+				`create binding events -> queue jobs ("#") in vhost tenant`,
+				"delete exchange stale in vhost tenant",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		changes := planChanges(current, desired, test.opts)
+
+		if len(changes) != len(test.expected) {
+			t.Fatalf("%+v: expected %d changes, got %d: %v", test.opts, len(test.expected), len(changes), changes)
+		}
+
+		for i, change := range changes {
+			if change.String() != test.expected[i] {
+				t.Errorf("%+v: change %d: expected %q, got %q", test.opts, i, test.expected[i], change.String())
+			}
+		}
+	}
+}
+
+func TestRabbit_ReconcileNotApplicable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+
+		switch req.URL.Path {
+		case "/api/vhosts":
+			w.Write([]byte(`[{"name":"tenant"}]`))
+		case "/api/queues":
+			w.Write([]byte(`[{"vhost":"tenant","name":"jobs","durable":false}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+
+	r := Auth("guest", "guest", ts.URL)
+	_, err := r.Reconcile(context.Background(), Topology{
+		Vhosts: []Vhost{{Name: "tenant"}},
+		Queues: []Queue{{Vhost: "tenant", Name: "jobs", Durable: true}},
+	}, ReconcileOptions{})
+	if err == nil || !strings.Contains(err.Error(), "queue jobs in vhost tenant") {
+		t.Errorf("expected an error naming the queue, got %v", err)
+	}
+}
@@ -2,13 +2,22 @@ package rabbitapi
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 )
 
 type User struct {
-	Name         string `json:"name`
-	PasswordHash string `json:"password_hash"`
-	Password     string `json:"password"`
-	Tags         string `json:"tags"`
+	Name             string `json:"name"`
+	PasswordHash     string `json:"password_hash"`
+	HashingAlgorithm string `json:"hashing_algorithm,omitempty"`
+	Password         string `json:"password"`
+	Tags             Tags   `json:"tags"`
+}
+
+// CurrentUser is the user whose credentials are used for api calls.
+type CurrentUser struct {
+	Name string `json:"name"`
+	Tags Tags   `json:"tags"`
 }
 
 // GetUsers() returns a list of all users.
@@ -44,12 +53,10 @@ func (r *Rabbit) GetUser(name string) (User, error) {
 	return user, nil
 }
 
-// CreateUser creates a new user with the given password and tags.
-// The tags key is mandatory (means you can give an empty string). tags is a
-// comma-separated list of tags for the user. Currently recognised tags are
-// "administrator", "monitoring" and "management" (please aware that tags
-// should be in the form of "foo, bar").
-func (r *Rabbit) CreateUser(name, password, tags string) error {
+// CreateUser creates a new user with the given password and tags. tags may be
+// nil. Currently recognised tags are TagAdministrator, TagMonitoring,
+// TagManagement, TagPolicymaker and TagImpersonator.
+func (r *Rabbit) CreateUser(name, password string, tags Tags) error {
 	user := &User{
 		Password: password,
 		Tags:     tags,
@@ -68,6 +75,61 @@ func (r *Rabbit) CreateUser(name, password, tags string) error {
 	return nil
 }
 
+// CreateUserWithHash creates a new user with an already hashed password (see
+// HashPassword), so the plaintext password is never sent to the broker.
+// algorithm is one of HashingSHA256, HashingSHA512 or HashingMD5; empty means
+// HashingSHA256, as for HashPassword. tags is the same as for CreateUser.
+func (r *Rabbit) CreateUserWithHash(name, passwordHash, algorithm string, tags Tags) error {
+	return r.putUser(name, passwordHashFields(passwordHash, algorithm, tags))
+}
+
+// putUser creates or updates the user with the given raw fields.
+func (r *Rabbit) putUser(name string, fields map[string]interface{}) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.doRequest("PUT", "/api/users/"+name, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// passwordHashFields returns the fields of a user with a hashed password.
+// The algorithm is always sent, as the default of the broker can differ from
+// the one of HashPassword.
+func passwordHashFields(hash, algorithm string, tags Tags) map[string]interface{} {
+	return map[string]interface{}{
+		"password_hash":     hash,
+		"hashing_algorithm": hashingAlgorithm(algorithm),
+		"tags":              tags,
+	}
+}
+
+// AddUserTags adds the given tags to an existing user, keeping its password.
+func (r *Rabbit) AddUserTags(name string, tags ...string) error {
+	user, err := r.GetUser(name)
+	if err != nil {
+		return err
+	}
+
+	return r.CreateUserWithHash(name, user.PasswordHash, user.HashingAlgorithm, user.Tags.Add(tags...))
+}
+
+// RemoveUserTags removes the given tags from an existing user, keeping its
+// password.
+func (r *Rabbit) RemoveUserTags(name string, tags ...string) error {
+	user, err := r.GetUser(name)
+	if err != nil {
+		return err
+	}
+
+	return r.CreateUserWithHash(name, user.PasswordHash, user.HashingAlgorithm, user.Tags.Remove(tags...))
+}
+
 // DeleteUser deletes an individual user.
 func (r *Rabbit) DeleteUser(name string) error {
 	_, err := r.doRequest("DELETE", "/api/users/"+name, nil)
@@ -93,3 +155,55 @@ func (r *Rabbit) GetUserPermissions(name string) ([]Permission, error) {
 
 	return list, nil
 }
+
+// Whoami returns the user whose credentials were passed to Auth.
+func (r *Rabbit) Whoami() (CurrentUser, error) {
+	body, err := r.doRequest("GET", "/api/whoami", nil)
+	if err != nil {
+		return CurrentUser{}, err
+	}
+
+	user := CurrentUser{}
+	err = json.Unmarshal(body, &user)
+	if err != nil {
+		return CurrentUser{}, err
+	}
+
+	return user, nil
+}
+
+// RequireTags returns an error if the credentials passed to Auth are invalid
+// or the user lacks any of the given tags. It's intended to be called at
+// startup, to fail fast instead of getting a 401 on the first api call which
+// needs the tags.
+func (r *Rabbit) RequireTags(tags ...string) error {
+	user, err := r.Whoami()
+	if err != nil {
+		if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == 401 {
+			return fmt.Errorf("invalid credentials for user '%s': %s", r.Username, err)
+		}
+		return err
+	}
+
+	missing := make([]string, 0)
+	for _, tag := range tags {
+		if !user.Tags.Has(tag) {
+			missing = append(missing, tag)
+		}
+	}
+
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("credentials of user '%s' lack the %s tag", user.Name, missing[0])
+	default:
+		return fmt.Errorf("credentials of user '%s' lack the %s tags", user.Name, strings.Join(missing, ", "))
+	}
+}
+
+// RequireAdministrator returns an error if the credentials passed to Auth
+// can't perform admin operations, like creating vhosts and users.
+func (r *Rabbit) RequireAdministrator() error {
+	return r.RequireTags(TagAdministrator)
+}
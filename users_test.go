@@ -1,6 +1,9 @@
 package rabbitapi
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -16,7 +19,7 @@ func TestRabbit_GetUsers(t *testing.T) {
 
 func TestRabbit_CreateUser(t *testing.T) {
 	r := Auth("guest", "guest", "http://localhost:15672")
-	err := r.CreateUser("zeynep", "deneme", "")
+	err := r.CreateUser("zeynep", "deneme", nil)
 	if err != nil {
 		t.Error(err)
 	} else {
@@ -53,3 +56,116 @@ func TestRabbit_GetUserPermissions(t *testing.T) {
 		t.Log("permissions for user 'guest'", permissions)
 	}
 }
+
+func TestRabbit_CreateUserWithHash(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	hash, err := HashPassword("deneme", HashingSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.CreateUserWithHash("zeynep-hash", hash, HashingSHA256, nil)
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("user 'zeynep-hash' created successfull")
+	}
+
+	r.DeleteUser("zeynep-hash")
+}
+
+func TestRabbit_CreateUserWithHashDefaultAlgorithm(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if body["hashing_algorithm"] != HashingSHA256 {
+			t.Errorf("unexpected hashing_algorithm %v", body["hashing_algorithm"])
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	hash, err := HashPassword("deneme", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Auth("guest", "guest", ts.URL).CreateUserWithHash("zeynep-hash", hash, "", nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRabbit_Whoami(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	user, err := r.Whoami()
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("current user:", user)
+	}
+}
+
+func TestRabbit_AddUserTags(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	err := r.CreateUser("zeynep-tags", "deneme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.DeleteUser("zeynep-tags")
+
+	err = r.AddUserTags("zeynep-tags", TagMonitoring, TagManagement)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = r.RemoveUserTags("zeynep-tags", TagManagement)
+	if err != nil {
+		t.Error(err)
+	}
+
+	user, err := r.GetUser("zeynep-tags")
+	if err != nil {
+		t.Error(err)
+	} else if !user.Tags.Has(TagMonitoring) || user.Tags.Has(TagManagement) {
+		t.Error("unexpected tags:", user.Tags)
+	}
+}
+
+func TestRabbit_RequireAdministrator(t *testing.T) {
+	r := Auth("guest", "guest", "http://localhost:15672")
+	err := r.RequireAdministrator()
+	if err != nil {
+		t.Error(err)
+	} else {
+		t.Log("user 'guest' is an administrator")
+	}
+}
+
+func TestRabbit_RequireTags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if username, _, _ := req.BasicAuth(); username != "monitor" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Write([]byte(`{"name":"monitor","tags":["monitoring"]}`))
+	}))
+	defer ts.Close()
+
+	r := Auth("monitor", "secret", ts.URL)
+	if err := r.RequireTags(TagMonitoring); err != nil {
+		t.Error(err)
+	}
+
+	err := r.RequireAdministrator()
+	if err == nil || err.Error() != "credentials of user 'monitor' lack the administrator tag" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	r = Auth("unknown", "secret", ts.URL)
+	if err := r.RequireTags(); err == nil {
+		t.Error("expected an error for invalid credentials")
+	}
+}
@@ -5,8 +5,20 @@ import (
 )
 
 type Vhost struct {
-	Name    string
-	Tracing bool
+	Name    string `json:"name"`
+	Tracing bool   `json:"tracing"`
+
+	MessageStats                  MessageStats `json:"message_stats"`
+	Messages                      int          `json:"messages"`
+	MessagesDetails               RateDetails  `json:"messages_details"`
+	MessagesReady                 int          `json:"messages_ready"`
+	MessagesReadyDetails          RateDetails  `json:"messages_ready_details"`
+	MessagesUnacknowledged        int          `json:"messages_unacknowledged"`
+	MessagesUnacknowledgedDetails RateDetails  `json:"messages_unacknowledged_details"`
+	RecvOct                       int64        `json:"recv_oct"`
+	RecvOctDetails                RateDetails  `json:"recv_oct_details"`
+	SendOct                       int64        `json:"send_oct"`
+	SendOctDetails                RateDetails  `json:"send_oct_details"`
 }
 
 // GetVhosts returns a list of all vhosts.
@@ -25,13 +37,14 @@ func (r *Rabbit) GetVhosts() ([]Vhost, error) {
 	return vhosts, nil
 }
 
-// GetVhost returns an individual vhost.
-func (r *Rabbit) GetVhost(name string) (Vhost, error) {
+// GetVhost returns an individual vhost. Samples of its queue lengths, message
+// and data rates can be requested with StatsOptions.
+func (r *Rabbit) GetVhost(name string, opts ...StatsOptions) (Vhost, error) {
 	if name == "/" {
 		name = "%2f"
 	}
 
-	body, err := r.doRequest("GET", "/api/vhosts/"+name, nil)
+	body, err := r.doRequest("GET", "/api/vhosts/"+name+statsQuery(opts), nil)
 	if err != nil {
 		return Vhost{}, err
 	}
@@ -0,0 +1,122 @@
+package rabbitapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+
+	// EventError is sent when polling the broker failed. Watching goes on
+	// with the next poll.
+	EventError EventType = "error"
+)
+
+// Event is a change of the broker topology seen by Watch.
+type Event struct {
+	Type  EventType
+	Kind  string // vhost, user, permission, exchange, queue, binding or policy
+	Vhost string
+	Name  string
+
+	// Fields are the changed fields of an updated object, see Diff.
+	Fields []FieldDiff
+
+	// Err is the polling error of an EventError.
+	Err error
+
+	// Time is when the change was seen.
+	Time time.Time
+}
+
+func (e Event) String() string {
+	if e.Type == EventError {
+		return fmt.Sprintf("error: %s", e.Err)
+	}
+
+	return describeDifference(string(e.Type), e.Kind, e.Vhost, e.Name, e.Fields)
+}
+
+var eventTypes = map[DiffType]EventType{
+	DiffAdded:   EventCreated,
+	DiffChanged: EventUpdated,
+	DiffRemoved: EventDeleted,
+}
+
+// Watch polls the vhosts, users, permissions, exchanges, queues, bindings
+// and policies of the broker every interval and sends an event for every
+// object created, updated or deleted since the previous poll. The first poll
+// happens right away and is the baseline; its error is returned. Changes
+// made and undone between two polls are not seen.
+//
+// The channel is closed when ctx is done. Events are not buffered, polling
+// waits for the receiver. interval must be positive.
+func (r *Rabbit) Watch(ctx context.Context, interval time.Duration) (<-chan Event, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid watch interval %s", interval)
+	}
+
+	previous, err := r.currentTopology(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	send := func(e Event) bool {
+		select {
+		case events <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			current, err := r.currentTopology(ctx)
+			now := time.Now()
+			if err != nil {
+				if ctx.Err() != nil || !send(Event{Type: EventError, Err: err, Time: now}) {
+					return
+				}
+				continue
+			}
+
+			// same broker, so a changed hash is a changed password
+			for _, d := range Diff(previous, current, DiffOptions{PasswordHashes: true}) {
+				e := Event{
+					Type:   eventTypes[d.Type],
+					Kind:   d.Kind,
+					Vhost:  d.Vhost,
+					Name:   d.Name,
+					Fields: d.Fields,
+					Time:   now,
+				}
+				if !send(e) {
+					return
+				}
+			}
+
+			previous = current
+		}
+	}()
+
+	return events, nil
+}
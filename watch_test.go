@@ -0,0 +1,96 @@
+package rabbitapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRabbit_Watch(t *testing.T) {
+	var mu sync.Mutex
+	exchanges := `[{"name":"events","vhost":"/","type":"topic","durable":true}]`
+	failing := false
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/api/exchanges":
+			w.Write([]byte(exchanges))
+		case "/api/vhosts":
+			w.Write([]byte(`[{"name":"/"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := Auth("guest", "guest", ts.URL).Watch(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := func(e string, fail bool) {
+		mu.Lock()
+		exchanges, failing = e, fail
+		mu.Unlock()
+	}
+
+	next := func() string {
+		select {
+		case e := <-events:
+			return e.String()
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+			return ""
+		}
+	}
+
+	set(`[{"name":"events","vhost":"/","type":"topic","durable":false},{"name":"audit","vhost":"/","type":"fanout"}]`, false)
+	if got, want := next(), "created exchange audit in vhost /"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := next(), "updated exchange events in vhost /: durable true -> false"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	set(`[]`, true)
+	if got, want := next(), "error: 503 Service Unavailable"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	set(`[{"name":"audit","vhost":"/","type":"fanout"}]`, false)
+	if got, want := next(), "deleted exchange events in vhost /"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	cancel()
+	for range events {
+		// drain until closed
+	}
+}
+
+func TestRabbit_WatchInterval(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request %s", req.URL.Path)
+	}))
+	defer ts.Close()
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := Auth("guest", "guest", ts.URL).Watch(context.Background(), interval); err == nil {
+			t.Errorf("interval %s: expected an error", interval)
+		}
+	}
+}